@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"channelspractice/internal/bench"
+)
+
+func main() {
+	alpha := flag.Float64("alpha", 0.05, "p-value below which a change is reported")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: bench [flags] <old> <new>\n\ncompares two saved go test -bench runs, like benchstat\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := compare(flag.Arg(0), flag.Arg(1), *alpha); err != nil {
+		fmt.Fprintf(os.Stderr, "bench: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func compare(oldPath, newPath string, alpha float64) error {
+	var runs [2]*bench.Results
+	for i, path := range []string{oldPath, newPath} {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		runs[i], err = bench.Parse(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to parse %s, %w", path, err)
+		}
+		if len(runs[i].Names) == 0 {
+			return fmt.Errorf("no benchmark results in %s", path)
+		}
+	}
+	bench.Compare(os.Stdout, runs[0], runs[1], alpha)
+	return nil
+}
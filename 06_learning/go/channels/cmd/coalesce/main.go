@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"channelspractice/internal/coalesce"
+)
+
+// The worker pool of lesson 18, where every job needs the price of a
+// product and looking one up is slow. Many jobs ask for the same product
+// at the same time, so the lookups are coalesced.
+func main() {
+	signalCtx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	var lookups atomic.Int32
+	prices := coalesce.New[string, int](coalesce.Options{TTL: 100 * time.Millisecond})
+	price := func(ctx context.Context, product string) (int, bool, error) {
+		return prices.Do(ctx, product, func(ctx context.Context) (int, error) {
+			lookups.Add(1)
+			select {
+			case <-time.After(200 * time.Millisecond):
+				return len(product) * 100, nil
+			case <-ctx.Done():
+				fmt.Printf("lookup of %s cancelled, nobody is waiting\n", product)
+				return 0, ctx.Err()
+			}
+		})
+	}
+
+	orders := []string{"tea", "coffee", "tea", "coffee", "tea", "cocoa", "tea", "coffee", "cocoa", "tea", "coffee", "tea"}
+	g, ctx := errgroup.WithContext(signalCtx)
+	jobs := make(chan string)
+	for workerID := range 6 {
+		g.Go(func() error {
+			for product := range jobs {
+				jobCtx, cancel := context.WithCancel(ctx)
+				// NOTE: worker 0 is impatient, giving up does not cancel the lookup the others wait for
+				if workerID == 0 {
+					jobCtx, cancel = context.WithTimeout(ctx, 50*time.Millisecond)
+				}
+				p, shared, err := price(jobCtx, product)
+				cancel()
+				if err != nil {
+					fmt.Printf("worker %d: %s: %v\n", workerID, product, err)
+					continue
+				}
+				fmt.Printf("worker %d: %s costs %d (shared %t)\n", workerID, product, p, shared)
+			}
+			return nil
+		})
+	}
+	g.Go(func() error {
+		defer close(jobs)
+		for _, product := range orders {
+			select {
+			case <-ctx.Done():
+				return nil
+			case jobs <- product:
+			}
+		}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		fmt.Printf("shutdown on worker error: %v\n", err)
+		return
+	}
+
+	// NOTE: the only waiter gives up, so the lookup itself is cancelled
+	timeoutCtx, cancelTimeout := context.WithTimeout(signalCtx, 50*time.Millisecond)
+	defer cancelTimeout()
+	if _, _, err := price(timeoutCtx, "matcha"); err != nil {
+		fmt.Printf("matcha: %v\n", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	s := prices.Stats()
+	fmt.Printf("%d jobs, %d lookups: %d shared, %d cached, %d cancelled\n", len(orders)+1, lookups.Load(), s.Shared, s.Cached, s.Cancelled)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"channelspractice/internal/future"
+)
+
+// slowOperation from lesson 11, started as a future instead of blocking
+// the caller.
+func slowOperation(ctx context.Context, d time.Duration) (string, error) {
+	select {
+	case <-time.After(d):
+		return fmt.Sprintf("operation completed after %v", d), nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// NOTE: lesson 1's one-shot channel
+	hello := future.Go(ctx, func(ctx context.Context) (string, error) {
+		return "hello from goroutine", nil
+	})
+	fmt.Println(hello.Await(ctx))
+
+	// NOTE: the caller keeps going while the operation runs and only waits 100ms for it
+	slow := future.Go(ctx, func(ctx context.Context) (string, error) { return slowOperation(ctx, 300*time.Millisecond) })
+	fmt.Printf("doing other work while the operation runs\n")
+	waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	_, err := slow.Await(waitCtx)
+	cancel()
+	fmt.Printf("gave up waiting: %v\n", err)
+	v, err := slow.Await(ctx)
+	fmt.Printf("the operation carried on: %q %v\n", v, err)
+
+	length := future.Then(hello, func(ctx context.Context, s string) (int, error) { return len(s), nil })
+	n, err := length.Await(ctx)
+	fmt.Printf("then: %d %v\n", n, err)
+
+	ops := func() []*future.Future[string] {
+		return []*future.Future[string]{
+			future.Go(ctx, func(ctx context.Context) (string, error) { return slowOperation(ctx, 30*time.Millisecond) }),
+			future.Go(ctx, func(ctx context.Context) (string, error) { return slowOperation(ctx, 10*time.Millisecond) }),
+			future.Go(ctx, func(ctx context.Context) (string, error) { return slowOperation(ctx, 20*time.Millisecond) }),
+		}
+	}
+	all, err := future.All(ctx, ops()...).Await(ctx)
+	fmt.Printf("all: %q %v\n", all, err)
+
+	failing := future.Go(ctx, func(ctx context.Context) (string, error) { return "", errors.New("mirror down") })
+	first, err := future.Any(ctx, append(ops(), failing)...).Await(ctx)
+	fmt.Printf("any: %q %v\n", first, err)
+	first, err = future.Race(ctx, append(ops(), failing)...).Await(ctx)
+	fmt.Printf("race: %q %v\n", first, err)
+
+	_, err = future.All(ctx, append(ops(), failing)...).Await(ctx)
+	fmt.Printf("all with a failure: %v\n", err)
+
+	// NOTE: a panic settles the future instead of crashing the program
+	boom := future.Go(ctx, func(ctx context.Context) (int, error) {
+		var m map[string]int
+		m["x"] = 1
+		return 0, nil
+	})
+	_, err = boom.Await(ctx)
+	var pe *future.PanicError
+	fmt.Printf("panic captured: %t, %v\n", errors.As(err, &pe), err)
+
+	// NOTE: cancelling a future cancels what was chained to it
+	long := future.Go(ctx, func(ctx context.Context) (string, error) { return slowOperation(ctx, time.Second) })
+	upper := future.Then(long, func(ctx context.Context, s string) (string, error) { return s + "!", nil })
+	long.Cancel()
+	_, err = upper.Await(ctx)
+	fmt.Printf("chained after cancel: %v\n", err)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"channelspractice/internal/scatter"
+)
+
+// A replicated read: every replica holds a version of the key, some are
+// slow and some fail. The reader needs a quorum of answers and takes the
+// newest version among them.
+func read(ctx context.Context, replica int, failRate float64) (int, error) {
+	latency := time.Duration(5+rand.IntN(80)) * time.Millisecond
+	select {
+	case <-time.After(latency):
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+	if rand.Float64() < failRate {
+		return 0, errors.New("replica unavailable")
+	}
+	// NOTE: replica 0 lags behind and still has the old version
+	if replica == 0 {
+		return 6, nil
+	}
+	return 7, nil
+}
+
+func main() {
+	replicas := flag.Int("replicas", 5, "number of replicas")
+	quorum := flag.Int("quorum", 3, "answers needed")
+	failRate := flag.Float64("fail", 0.3, "fraction of reads that fail")
+	rounds := flag.Int("rounds", 4, "reads to perform")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	for round := range *rounds {
+		start := time.Now()
+		res, err := scatter.Gather(ctx, *replicas, func(ctx context.Context, replica int) (int, error) {
+			return read(ctx, replica, *failRate)
+		}, *quorum)
+		if res == nil {
+			fmt.Printf("read %d: %v\n", round, err)
+			return
+		}
+
+		for _, b := range res.Branches {
+			status := fmt.Sprintf("version %d", b.Value)
+			switch {
+			case b.Cancelled:
+				status = "cancelled"
+			case b.Err != nil:
+				status = b.Err.Error()
+			}
+			fmt.Printf("  replica %d  %-6v %s\n", b.Index, b.Latency.Round(time.Millisecond), status)
+		}
+		if err != nil {
+			fmt.Printf("read %d failed after %v: %v\n\n", round, time.Since(start).Round(time.Millisecond), err)
+			continue
+		}
+		fmt.Printf("read %d: version %d from %v after %v\n\n", round, slices.Max(res.Values()), res.Values(), time.Since(start).Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os/signal"
+	"runtime"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"channelspractice/internal/race"
+)
+
+// A backend that usually answers in about 10ms but now and then stalls,
+// like a replica in a GC pause. Hedging turns the stall into the latency
+// of the backup attempt.
+func backend(ctx context.Context, slow float64) (string, error) {
+	latency := time.Duration(8+rand.IntN(5)) * time.Millisecond
+	if rand.Float64() < slow {
+		latency = 300 * time.Millisecond
+	}
+	select {
+	case <-time.After(latency):
+		return fmt.Sprintf("answer after %v", latency), nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func main() {
+	requests := flag.Int("requests", 200, "requests per run")
+	delay := flag.Duration("delay", 20*time.Millisecond, "hedge delay")
+	attempts := flag.Int("attempts", 3, "maximum attempts per request")
+	slow := flag.Float64("slow", 0.05, "fraction of calls that stall")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// NOTE: the first successful mirror wins, the failing one and the slow one are cancelled
+	mirrors := []func(context.Context) (string, error){
+		func(ctx context.Context) (string, error) { return "", errors.New("mirror a is down") },
+		func(ctx context.Context) (string, error) { return backend(ctx, 1) },
+		func(ctx context.Context) (string, error) { return backend(ctx, 0) },
+	}
+	v, winner, err := race.FirstOf(ctx, mirrors...)
+	fmt.Printf("first of 3 mirrors: %q from mirror %d, err %v\n", v, winner, err)
+
+	before := runtime.NumGoroutine()
+	run(ctx, "plain ", *requests, 0, 1, *slow)
+	run(ctx, "hedged", *requests, *delay, *attempts, *slow)
+	// NOTE: losing attempts are cancelled and awaited, so nothing is left running
+	fmt.Printf("goroutines before %d, after %d\n", before, runtime.NumGoroutine())
+}
+
+func run(ctx context.Context, name string, n int, delay time.Duration, attempts int, slow float64) {
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		wins      = make([]int, attempts)
+		wg        sync.WaitGroup
+		sem       = make(chan struct{}, 20)
+	)
+	for range n {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			start := time.Now()
+			_, winner, err := race.Hedge(ctx, func(ctx context.Context, attempt int) (string, error) {
+				return backend(ctx, slow)
+			}, delay, attempts)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			latencies = append(latencies, time.Since(start))
+			wins[winner]++
+		}()
+	}
+	wg.Wait()
+
+	if len(latencies) == 0 {
+		return
+	}
+	slices.Sort(latencies)
+	p := func(q float64) time.Duration {
+		return latencies[int(q*float64(len(latencies)-1))].Round(time.Millisecond)
+	}
+	fmt.Printf("%s p50 %-6v p99 %-6v max %-6v wins by attempt %v\n", name, p(0.5), p(0.99), p(1), wins)
+}
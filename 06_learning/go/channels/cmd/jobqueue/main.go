@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"channelspractice/internal/jobqueue"
+	"channelspractice/internal/logx"
+	"channelspractice/internal/pause"
+)
+
+// The worker pool of lesson 18 fed by a durable queue: stop it with
+// Ctrl+C or -crash-after and run it again, the jobs that were not acked
+// are processed on the next run. Instead of failing the whole group like
+// lesson 18, a failing job is nacked and retried, and a stuck worker loses
+// its job to another one once the visibility timeout expires.
+func main() {
+	dir := flag.String("dir", "jobqueue-data", "directory holding the queue log")
+	enqueue := flag.Int("enqueue", 10, "jobs to add when the queue is empty")
+	workers := flag.Int("workers", 3, "number of workers")
+	work := flag.Duration("work", time.Second, "time each job takes")
+	syncFlag := flag.String("sync", "always", "fsync policy: always, interval or never")
+	crashAfter := flag.Int("crash-after", 0, "exit without closing the queue after this many acks, 0 never")
+	visibility := flag.Duration("visibility", 3*time.Second, "time a job may stay unacked before it is delivered again")
+	flaky := flag.Int("flaky", 7, "job that fails its first two attempts, -1 none")
+	stall := flag.Int("stall", -1, "job whose first attempt hangs past the visibility timeout, -1 none")
+	maxAttempts := flag.Int("max-attempts", 3, "attempts after which a failing job is dropped")
+	flag.Parse()
+	// NOTE: worker lines are structured, LOG_FORMAT=json switches them to JSON, see internal/logx
+	logx.FromEnv()
+
+	policies := map[string]jobqueue.SyncPolicy{"always": jobqueue.SyncAlways, "interval": jobqueue.SyncInterval, "never": jobqueue.SyncNever}
+	policy, ok := policies[*syncFlag]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown -sync policy %q\n", *syncFlag)
+		os.Exit(2)
+	}
+
+	q, err := jobqueue.Open(*dir, jobqueue.Options{Sync: policy, Visibility: *visibility})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open queue: %v\n", err)
+		os.Exit(1)
+	}
+	defer q.Close()
+
+	waiting, _ := q.Len()
+	if waiting > 0 {
+		fmt.Printf("recovered %d unacked jobs from %s\n", waiting, *dir)
+	} else {
+		for job := range *enqueue {
+			if _, err := q.Enqueue(fmt.Appendf(nil, "job %d", job)); err != nil {
+				fmt.Printf("failed to enqueue: %v\n", err)
+				return
+			}
+		}
+		fmt.Printf("enqueued %d jobs\n", *enqueue)
+	}
+
+	signalCtx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	g, ctx := errgroup.WithContext(signalCtx)
+
+	// NOTE: kill -USR1 stops workers from taking new jobs, kill -USR2 lets them carry on
+	ctrl := pause.New()
+	pause.OnSignals(ctx, ctrl, func(s pause.State) {
+		waiting, inflight := q.Len()
+		fmt.Printf("workers %s, %d jobs waiting, %d in flight, paused for %v in total\n", s, waiting, inflight, ctrl.PausedFor().Round(time.Millisecond))
+	})
+
+	acks := make(chan struct{})
+	for workerID := range *workers {
+		w := worker{id: workerID, q: q, pause: ctrl, work: *work, flaky: *flaky, stall: *stall, maxAttempts: *maxAttempts, stallFor: 2 * *visibility}
+		g.Go(func() error {
+			return w.run(ctx, acks)
+		})
+	}
+	g.Go(func() error {
+		for done := 1; ; done++ {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-acks:
+			}
+			if *crashAfter > 0 && done == *crashAfter {
+				fmt.Printf("simulating a crash after %d acks\n", done)
+				os.Exit(1)
+			}
+			if waiting, inflight := q.Len(); waiting+inflight == 0 {
+				cancel()
+				return nil
+			}
+		}
+	})
+
+	if err := g.Wait(); err != nil {
+		fmt.Printf("shutdown on worker error: %v\n", err)
+		return
+	}
+	if waiting, inflight := q.Len(); waiting+inflight > 0 {
+		fmt.Printf("interrupted, %d jobs left for the next run\n", waiting+inflight)
+		return
+	}
+	fmt.Printf("finished processing jobs\n")
+}
+
+type worker struct {
+	id          int
+	q           *jobqueue.Queue
+	pause       *pause.Controller
+	work        time.Duration
+	flaky       int
+	stall       int
+	stallFor    time.Duration
+	maxAttempts int
+}
+
+func (w worker) run(ctx context.Context, acks chan<- struct{}) error {
+	log := slog.With(logx.Worker(w.id))
+	for {
+		if err := w.pause.Wait(ctx); err != nil {
+			log.Info("shutting down")
+			return nil
+		}
+		job, err := w.q.Dequeue(ctx)
+		if errors.Is(err, context.Canceled) {
+			log.Info("shutting down")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var n int
+		fmt.Sscanf(string(job.Payload), "job %d", &n)
+		log := log.With(logx.Job(job.ID), logx.Attempt(job.Attempts), "payload", string(job.Payload))
+		log.Info("processing")
+
+		work := w.work
+		if n == w.stall && job.Attempts == 1 {
+			work = w.stallFor
+		}
+		select {
+		case <-ctx.Done():
+			// NOTE: no ack, the job is delivered again on the next run
+			log.Info("shutting down, job not acked")
+			return nil
+		case <-time.After(work):
+		}
+
+		if n == w.flaky && job.Attempts < 3 {
+			if job.Attempts < w.maxAttempts {
+				log.Warn("failed, nack")
+				if err := w.q.Nack(job.ID); err != nil && !errors.Is(err, jobqueue.ErrUnknownJob) {
+					return err
+				}
+				continue
+			}
+			log.Error("failed too often, dropping it")
+		}
+
+		err = w.q.Ack(job.ID)
+		if errors.Is(err, jobqueue.ErrUnknownJob) {
+			// NOTE: the visibility timeout expired and another worker already finished the job
+			log.Warn("redelivered and acked by another worker")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		select {
+		case acks <- struct{}{}:
+		case <-ctx.Done():
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"channelspractice/internal/join"
+)
+
+// generator sends count values, one every interval, like the generators of
+// lesson 20 but at different rates.
+func generator(ctx context.Context, prefix string, count int, interval time.Duration) <-chan string {
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		for i := range count {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- fmt.Sprintf("%s%d", prefix, i):
+			}
+		}
+	}()
+	return out
+}
+
+func main() {
+	signalCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// NOTE: orders arrive every 30ms, prices every 50ms, and the price feed stops after 3 values
+	operators := []struct {
+		name string
+		join func(ctx context.Context, orders, prices <-chan string) <-chan join.Pair[string, string]
+	}{
+		{"zip", join.Zip[string, string]},
+		{"combine latest", join.CombineLatest[string, string]},
+		{"with latest from", join.WithLatestFrom[string, string]},
+	}
+	for _, op := range operators {
+		ctx, cancel := context.WithCancel(signalCtx)
+		start := time.Now()
+		fmt.Printf("%s\n", op.name)
+		for p := range op.join(ctx, generator(ctx, "order", 6, 30*time.Millisecond), generator(ctx, "price", 3, 50*time.Millisecond)) {
+			fmt.Printf("  %-6v %s %s\n", time.Since(start).Round(10*time.Millisecond), p.Left, p.Right)
+		}
+		// NOTE: stops the generator still sending after the output closed
+		cancel()
+		fmt.Printf("  closed after %v\n", time.Since(start).Round(10*time.Millisecond))
+	}
+}
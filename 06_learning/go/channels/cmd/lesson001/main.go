@@ -1,12 +1,23 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+
+	"channelspractice/internal/config"
+)
 
 func main() {
+	cfg := config.New("lesson001")
+	message := cfg.String("message", "hello from goroutine", "message sent by the goroutine")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	ch := make(chan string)
 	go func(ch chan string) {
 		defer close(ch)
-		ch <- "hello from goroutine"
+		ch <- *message
 	}(ch)
 	fmt.Println(<-ch)
 
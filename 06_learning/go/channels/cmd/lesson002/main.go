@@ -1,12 +1,23 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+
+	"channelspractice/internal/config"
+)
 
 func main() {
-	ch := make(chan int, 3)
+	cfg := config.New("lesson002")
+	values := cfg.Int("values", 3, "values sent, also the channel buffer size")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
+	ch := make(chan int, *values)
 
 	// NOTE: Sending values to the channel that is not in a goroutine works because it is a buffered channel
-	for i := range 3 {
+	for i := range *values {
 		ch <- (i + 1)
 	}
 
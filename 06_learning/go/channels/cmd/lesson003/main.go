@@ -1,19 +1,32 @@
+//go:build !chanx
+
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+
+	"channelspractice/internal/config"
+)
 
 func main() {
+	cfg := config.New("lesson003")
+	count := cfg.Int("count", 5, "values sent by the producer")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	//NOTE: The bidirectional chan int in main converts automatically when passed to the child functions
 	ch := make(chan int)
 
-	go producer(ch)
+	go producer(ch, *count)
 
 	consumer(ch)
 }
 
 // NOTE: producer can only send and close (appropriate for a producer)
-func producer(ch chan<- int) {
-	for i := range 5 {
+func producer(ch chan<- int, count int) {
+	for i := range count {
 		ch <- (i + 1)
 	}
 	close(ch)
@@ -0,0 +1,45 @@
+//go:build chanx
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"channelspractice/internal/chanx"
+	"channelspractice/internal/config"
+	"channelspractice/internal/recorder"
+)
+
+func main() {
+	cfg := config.New("lesson003")
+	count := cfg.Int("count", 5, "values sent by the producer")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
+	defer recorder.FromEnv()()
+	chanx.Label("main")
+
+	//NOTE: ch.Sender() and ch.Receiver() are the chanx versions of the automatic chan int -> chan<- int / <-chan int conversion
+	ch := chanx.Make[int]("ch", 0)
+
+	chanx.Go("producer", func() { producer(ch.Sender(), *count) })
+
+	consumer(ch.Receiver())
+}
+
+// NOTE: producer can only send and close (appropriate for a producer)
+func producer(ch chanx.Sender[int], count int) {
+	for i := range count {
+		ch.Send(i + 1)
+	}
+	ch.Close()
+}
+
+// NOTE: consumer can only receive (can't accidentally close or send)
+func consumer(ch chanx.Receiver[int]) {
+	for value := range ch.All() {
+		fmt.Println(value)
+	}
+}
@@ -2,20 +2,30 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"time"
+
+	"channelspractice/internal/config"
 )
 
 func main() {
+	cfg := config.New("lesson004")
+	delay1 := cfg.Duration("delay1", 100*time.Millisecond, "delay before channel 1 sends")
+	delay2 := cfg.Duration("delay2", 200*time.Millisecond, "delay before channel 2 sends")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	ch1 := make(chan string)
 	ch2 := make(chan string)
 
 	go func(ch chan<- string) {
-		time.Sleep(100 * time.Millisecond)
+		time.Sleep(*delay1)
 		ch <- "from channel 1"
 	}(ch1)
 
 	go func(ch chan<- string) {
-		time.Sleep(200 * time.Millisecond)
+		time.Sleep(*delay2)
 		ch <- "from channel 2"
 	}(ch2)
 
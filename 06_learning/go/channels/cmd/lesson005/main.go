@@ -2,21 +2,31 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"time"
+
+	"channelspractice/internal/config"
 )
 
 func main() {
+	cfg := config.New("lesson005")
+	work := cfg.Duration("work", 500*time.Millisecond, "how long the operation takes")
+	timeout := cfg.Duration("timeout", 600*time.Millisecond, "how long main waits for it")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	ch := make(chan string)
 
 	go func(ch chan<- string) {
-		time.Sleep(500 * time.Millisecond)
+		time.Sleep(*work)
 		ch <- "operation completed"
 	}(ch)
 
 	select {
 	case msg := <-ch:
 		fmt.Println(msg)
-	case <-time.After(600 * time.Millisecond):
+	case <-time.After(*timeout):
 		fmt.Println("operation took too long")
 	}
 }
@@ -2,10 +2,20 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"time"
+
+	"channelspractice/internal/config"
 )
 
 func main() {
+	cfg := config.New("lesson006")
+	interval := cfg.Duration("interval", 100*time.Millisecond, "delay between results")
+	count := cfg.Int("count", 5, "results read before stopping the worker")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	done := make(chan struct{})
 	results := make(chan int)
 
@@ -13,7 +23,7 @@ func main() {
 		count := 0
 
 		for {
-			time.Sleep(100 * time.Millisecond)
+			time.Sleep(*interval)
 			select {
 			case <-done:
 				return
@@ -24,7 +34,7 @@ func main() {
 
 	}(results, done)
 
-	for range 5 {
+	for range *count {
 		fmt.Println(<-results)
 	}
 
@@ -2,35 +2,71 @@ package main
 
 import (
 	"fmt"
-)
+	"log/slog"
+	"os"
 
-const numOfJobs = 5
+	"channelspractice/internal/config"
+	"channelspractice/internal/logx"
+	"channelspractice/internal/safe"
+)
 
 func main() {
-	jobs := make(chan int, 10)
-	results := make(chan int, 10)
-
-	for workerID := range 3 {
-		go worker(workerID, jobs, results)
+	// NOTE: stdout keeps the plain progress, the structured log goes to stderr
+	logx.FromEnvTo(os.Stderr)
+	cfg := config.New("lesson007")
+	numOfWorkers := cfg.IntMin("workers", 3, 1, "number of workers")
+	numOfJobs := cfg.IntMin("jobs", 5, 0, "number of jobs")
+	buffer := cfg.IntMin("buffer", 10, 1, "buffer size of the jobs and results channels")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
 	}
 
-	for num := range numOfJobs {
-		jobs <- (num + 1)
+	jobs := make(chan int, *buffer)
+	results := make(chan int, *buffer)
+	failed := make(chan error, *buffer)
+
+	for workerID := range *numOfWorkers {
+		go worker(workerID, jobs, results, failed, double)
 	}
 
-	close(jobs)
+	// NOTE: send from a goroutine, more jobs than the buffer holds would block main before it reads a result
+	go func() {
+		defer close(jobs)
+		for num := range *numOfJobs {
+			jobs <- (num + 1)
+		}
+	}()
 
-	for range numOfJobs {
-		result := <-results
-		fmt.Printf("result: %d\n", result)
+	for range *numOfJobs {
+		select {
+		case result := <-results:
+			fmt.Printf("result: %d\n", result)
+		case err := <-failed:
+			fmt.Printf("failed: %v\n", err)
+		}
 	}
 
 	close(results)
 }
 
-func worker(workerID int, jobs <-chan int, results chan<- int) {
+func double(job int) int {
+	return 2 * job
+}
+
+// worker runs process on every job. A job whose process panics lands on
+// failed instead of crashing the lesson.
+func worker(workerID int, jobs <-chan int, results chan<- int, failed chan<- error, process func(int) int) {
+	log := slog.With(logx.Worker(workerID))
 	for job := range jobs {
-		fmt.Printf("worker %d is processing job %d\n", workerID, job)
-		results <- 2 * job
+		err := safe.Call(func() error {
+			fmt.Printf("worker %d is processing job %d\n", workerID, job)
+			log.Info("processing", logx.Job(job))
+			results <- process(job)
+			return nil
+		})
+		if err != nil {
+			log.Error("job failed", logx.Job(job), "err", err)
+			failed <- fmt.Errorf("job %d, %w", job, err)
+		}
 	}
 }
@@ -2,28 +2,43 @@ package main
 
 import (
 	"fmt"
+	"log/slog"
+	"os"
 	"sync"
-)
 
-const numOfJobs = 5
+	"channelspractice/internal/config"
+	"channelspractice/internal/logx"
+	"channelspractice/internal/safe"
+)
 
 func main() {
+	// NOTE: stdout keeps the plain progress, the structured log goes to stderr
+	logx.FromEnvTo(os.Stderr)
+	cfg := config.New("lesson008")
+	numOfWorkers := cfg.IntMin("workers", 3, 1, "number of workers")
+	numOfJobs := cfg.IntMin("jobs", 5, 0, "number of jobs")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	var wg sync.WaitGroup
 	jobs := make(chan int)
 	results := make(chan int)
+	failed := make(chan error, *numOfJobs)
 
-	for workerID := range 3 {
+	for workerID := range *numOfWorkers {
 		wg.Add(1)
-		go worker(workerID, jobs, results, &wg)
+		go worker(workerID, jobs, results, failed, double, &wg)
 	}
 
 	go func(wg *sync.WaitGroup, results chan<- int) {
 		wg.Wait()
 		close(results)
+		close(failed)
 	}(&wg, results)
 
 	go func(jobs chan<- int) {
-		for num := range numOfJobs {
+		for num := range *numOfJobs {
 			jobs <- (num + 1)
 		}
 
@@ -33,12 +48,30 @@ func main() {
 	for result := range results {
 		fmt.Printf("result: %d\n", result)
 	}
+	for err := range failed {
+		fmt.Printf("failed: %v\n", err)
+	}
+}
+
+func double(job int) int {
+	return 2 * job
 }
 
-func worker(workerID int, jobs <-chan int, results chan<- int, wg *sync.WaitGroup) {
+// worker runs process on every job. A job whose process panics lands on
+// failed instead of crashing the lesson.
+func worker(workerID int, jobs <-chan int, results chan<- int, failed chan<- error, process func(int) int, wg *sync.WaitGroup) {
 	defer wg.Done()
+	log := slog.With(logx.Worker(workerID))
 	for job := range jobs {
-		fmt.Printf("worker %d is processing job %d\n", workerID, job)
-		results <- 2 * job
+		err := safe.Call(func() error {
+			fmt.Printf("worker %d is processing job %d\n", workerID, job)
+			log.Info("processing", logx.Job(job))
+			results <- process(job)
+			return nil
+		})
+		if err != nil {
+			log.Error("job failed", logx.Job(job), "err", err)
+			failed <- fmt.Errorf("job %d, %w", job, err)
+		}
 	}
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"channelspractice/internal/safe"
+)
+
+// TestWorkerRecoversPanic runs the lesson's worker with a job that panics:
+// the job lands on failed as a *safe.PanicError with its stack and the
+// worker goes on with the next job.
+func TestWorkerRecoversPanic(t *testing.T) {
+	jobs := make(chan int, 3)
+	results := make(chan int, 3)
+	failed := make(chan error, 3)
+	for _, job := range []int{1, 2, 3} {
+		jobs <- job
+	}
+	close(jobs)
+	process := func(job int) int {
+		if job == 2 {
+			panic("bad job 2")
+		}
+		return double(job)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	worker(0, jobs, results, failed, process, &wg)
+	close(results)
+	close(failed)
+
+	var got []int
+	for r := range results {
+		got = append(got, r)
+	}
+	if !slices.Equal(got, []int{2, 6}) {
+		t.Errorf("results = %v, want [2 6]", got)
+	}
+	err := <-failed
+	var pe *safe.PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("failed = %v, want a *safe.PanicError", err)
+	}
+	if pe.Value != "bad job 2" || !strings.Contains(err.Error(), "job 2") {
+		t.Errorf("failed = %v with value %v, want the panic of job 2", err, pe.Value)
+	}
+	if !strings.Contains(string(pe.Stack), "TestWorkerRecoversPanic") {
+		t.Errorf("stack does not show where the panic happened:\n%s", pe.Stack)
+	}
+}
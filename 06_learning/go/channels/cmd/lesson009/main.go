@@ -1,18 +1,30 @@
+//go:build !chanx
+
 package main
 
 import (
 	"fmt"
+	"os"
 	"sync"
+
+	"channelspractice/internal/config"
 )
 
 func main() {
+	cfg := config.New("lesson009")
+	workers := cfg.IntMin("workers", 3, 1, "number of square workers")
+	numbers := cfg.Int("numbers", 9, "numbers generated")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	var wg sync.WaitGroup
 
 	results := make(chan int)
 
-	gen := generator()
+	gen := generator(*numbers)
 
-	for range 3 {
+	for range *workers {
 		wg.Add(1)
 		sq := square(gen)
 		go func() {
@@ -33,11 +45,11 @@ func main() {
 	}
 }
 
-func generator() <-chan int {
+func generator(numbers int) <-chan int {
 	out := make(chan int)
 	go func() {
 		defer close(out)
-		for i := range 9 {
+		for i := range numbers {
 			out <- (i + 1)
 		}
 	}()
@@ -0,0 +1,74 @@
+//go:build chanx
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"channelspractice/internal/chanx"
+	"channelspractice/internal/config"
+	"channelspractice/internal/recorder"
+)
+
+func main() {
+	cfg := config.New("lesson009")
+	workers := cfg.IntMin("workers", 3, 1, "number of square workers")
+	numbers := cfg.Int("numbers", 9, "numbers generated")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
+	defer recorder.FromEnv()()
+	chanx.Label("main")
+
+	var wg sync.WaitGroup
+
+	results := chanx.Make[int]("results", 0)
+
+	gen := generator(*numbers)
+
+	for id := range *workers {
+		wg.Add(1)
+		sq := square(id, gen)
+		chanx.Go("merge "+strconv.Itoa(id), func() {
+			defer wg.Done()
+			for s := range sq.All() {
+				results.Send(s)
+			}
+		})
+	}
+
+	chanx.Go("closer", func() {
+		wg.Wait()
+		results.Close()
+	})
+
+	for result := range results.All() {
+		fmt.Println(result)
+	}
+}
+
+func generator(numbers int) chanx.Receiver[int] {
+	out := chanx.Make[int]("numbers", 0)
+	chanx.Go("generator", func() {
+		defer out.Close()
+		for i := range numbers {
+			out.Send(i + 1)
+		}
+	})
+	return out.Receiver()
+}
+
+func square(id int, generator chanx.Receiver[int]) chanx.Receiver[int] {
+	out := chanx.Make[int]("squares "+strconv.Itoa(id), 0)
+	chanx.Go("square "+strconv.Itoa(id), func() {
+		defer out.Close()
+		for num := range generator.All() {
+			out.Send(num * num)
+		}
+	})
+	return out.Receiver()
+}
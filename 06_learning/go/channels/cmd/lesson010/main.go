@@ -3,26 +3,53 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
+
+	"channelspractice/internal/config"
+	"channelspractice/internal/pause"
 )
 
 func main() {
+	cfg := config.New("lesson010")
+	count := cfg.Int("count", 5, "values read from the generator")
+	pauseAt := cfg.Int("pause-at", 2, "index of the value after which the generator is paused")
+	pauseFor := cfg.Duration("pause-for", 300*time.Millisecond, "how long the generator stays paused")
+	interval := cfg.Duration("interval", 100*time.Millisecond, "delay between values")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
-	gen := generator(ctx)
-	for range 5 {
+	// NOTE: unlike cancel, a pause keeps the generator and its counter alive
+	ctrl := pause.New()
+	gen := generator(ctx, ctrl, *interval)
+	for i := range *count {
 		value := <-gen
 		fmt.Printf("%d\n", value)
+		if i == *pauseAt {
+			ctrl.Pause()
+			fmt.Printf("generator paused\n")
+			time.AfterFunc(*pauseFor, func() {
+				fmt.Printf("generator resumed after %v\n", ctrl.PausedFor().Round(100*time.Millisecond))
+				ctrl.Resume()
+			})
+		}
 	}
 	cancel()
 }
 
-func generator(ctx context.Context) <-chan int {
+func generator(ctx context.Context, ctrl *pause.Controller, interval time.Duration) <-chan int {
 	out := make(chan int)
 	go func() {
 		defer close(out)
 		counter := 0
 		for {
-			time.Sleep(100 * time.Millisecond)
+			if err := ctrl.Wait(ctx); err != nil {
+				fmt.Printf("generator stopped\n")
+				return
+			}
+			time.Sleep(interval)
 			select {
 			case <-ctx.Done():
 				fmt.Printf("generator stopped\n")
@@ -5,13 +5,23 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"os"
 	"time"
+
+	"channelspractice/internal/config"
 )
 
 func main() {
-	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	cfg := config.New("lesson011")
+	timeout := cfg.Duration("timeout", 100*time.Millisecond, "deadline of the context")
+	work := cfg.Duration("work", 300*time.Millisecond, "how long the slow operation takes")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
 	defer cancel()
-	res, err := slowOperation(ctx)
+	res, err := slowOperation(ctx, *work)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			log.Fatalf("context deadline exceeded")
@@ -22,9 +32,9 @@ func main() {
 	fmt.Println(res)
 }
 
-func slowOperation(ctx context.Context) (string, error) {
+func slowOperation(ctx context.Context, work time.Duration) (string, error) {
 	select {
-	case <-time.After(300 * time.Millisecond):
+	case <-time.After(work):
 		return "operation completed", nil
 	case <-ctx.Done():
 		return "", ctx.Err()
@@ -3,37 +3,55 @@ package main
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 	"time"
+
+	"channelspractice/internal/config"
+	"channelspractice/internal/logx"
 )
 
 func main() {
+	cfg := config.New("lesson012")
+	workers := cfg.IntMin("workers", 3, 1, "number of workers")
+	interval := cfg.Duration("interval", 500*time.Millisecond, "delay between processing ticks")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
+	// NOTE: stdout keeps the plain progress, the structured log goes to stderr
+	log := logx.FromEnvTo(os.Stderr)
 	var wg sync.WaitGroup
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 
 	defer stop()
 
-	for id := range 3 {
+	for id := range *workers {
 		wg.Add(1)
-		go worker(ctx, id+1, &wg)
+		go worker(ctx, id+1, *interval, &wg)
 	}
 
 	wg.Wait()
 
-	fmt.Printf("all workers stopped, exitting\n")
+	fmt.Printf("all workers stopped, exiting\n")
+	log.Info("all workers stopped, exiting")
 }
 
-func worker(ctx context.Context, id int, wg *sync.WaitGroup) {
+func worker(ctx context.Context, id int, interval time.Duration, wg *sync.WaitGroup) {
 	defer wg.Done()
+	log := slog.With(logx.Worker(id))
 	for {
 		select {
 		case <-ctx.Done():
-			fmt.Printf("worked %d: shutting down\n", id)
+			fmt.Printf("worker %d: shutting down\n", id)
+			log.Info("shutting down")
 			return
-		case <-time.After(500 * time.Millisecond):
+		case <-time.After(interval):
 			fmt.Printf("worker %d: processing...\n", id)
+			log.Info("processing")
 		}
 	}
 }
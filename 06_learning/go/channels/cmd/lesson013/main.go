@@ -3,17 +3,27 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 
 	"golang.org/x/sync/errgroup"
+
+	"channelspractice/internal/config"
 )
 
 func main() {
+	cfg := config.New("lesson013")
+	failAfter := cfg.Duration("fail-after", 200*time.Millisecond, "when the failing worker returns its error")
+	work := cfg.Duration("work", 500*time.Millisecond, "how long the other workers take")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	g, ctx := errgroup.WithContext(context.Background())
 
 	g.Go(func() error {
 		select {
-		case <-time.After(200 * time.Millisecond):
+		case <-time.After(*failAfter):
 			return fmt.Errorf("failed to run worker")
 		case <-ctx.Done():
 			fmt.Printf("closing worker\n")
@@ -23,7 +33,7 @@ func main() {
 
 	g.Go(func() error {
 		select {
-		case <-time.After(500 * time.Millisecond):
+		case <-time.After(*work):
 			fmt.Printf("finished fetching data")
 			return nil
 		case <-ctx.Done():
@@ -34,7 +44,7 @@ func main() {
 
 	g.Go(func() error {
 		select {
-		case <-time.After(500 * time.Millisecond):
+		case <-time.After(*work):
 			fmt.Printf("finished fetching data")
 			return nil
 		case <-ctx.Done():
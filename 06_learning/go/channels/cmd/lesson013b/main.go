@@ -3,21 +3,31 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"golang.org/x/sync/errgroup"
+
+	"channelspractice/internal/config"
 )
 
 func main() {
+	cfg := config.New("lesson013b")
+	failAfter := cfg.Duration("fail-after", 3*time.Second, "when the failing worker returns its error")
+	work := cfg.Duration("work", 5*time.Second, "how long the other workers take")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	signalCtx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 	g, ctx := errgroup.WithContext(signalCtx)
 
 	g.Go(func() error {
 		select {
-		case <-time.After(5 * time.Second):
+		case <-time.After(*work):
 			fmt.Printf("worker finished processing\n")
 			return nil
 		case <-ctx.Done():
@@ -28,7 +38,7 @@ func main() {
 
 	g.Go(func() error {
 		select {
-		case <-time.After(5 * time.Second):
+		case <-time.After(*work):
 			fmt.Printf("worker finished processing\n")
 			return nil
 		case <-ctx.Done():
@@ -39,7 +49,7 @@ func main() {
 
 	g.Go(func() error {
 		select {
-		case <-time.After(3 * time.Second):
+		case <-time.After(*failAfter):
 			return fmt.Errorf("worker failed\n")
 		case <-ctx.Done():
 			fmt.Printf("worker shutting down\n")
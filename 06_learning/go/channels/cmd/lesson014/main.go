@@ -2,21 +2,32 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"sync"
 	"time"
+
+	"channelspractice/internal/config"
 )
 
 func main() {
+	cfg := config.New("lesson014")
+	concurrency := cfg.IntMin("concurrency", 3, 1, "jobs running at the same time")
+	jobs := cfg.IntMin("jobs", 10, 0, "number of jobs")
+	work := cfg.Duration("work", 1*time.Second, "how long each job takes")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	var wg sync.WaitGroup
-	sem := make(chan struct{}, 3)
+	sem := make(chan struct{}, *concurrency)
 
-	for id := range 10 {
+	for id := range *jobs {
 		wg.Add(1)
 		sem <- struct{}{}
 		go func(id int) {
 			defer wg.Done()
 			defer func() { <-sem }()
-			process(id)
+			process(id, *work)
 		}(id)
 	}
 
@@ -25,8 +36,8 @@ func main() {
 	fmt.Printf("all jobs completed\n")
 }
 
-func process(id int) {
+func process(id int, work time.Duration) {
 	fmt.Printf("starting job %d\n", id)
-	time.Sleep(1 * time.Second)
+	time.Sleep(work)
 	fmt.Printf("finished job %d\n", id)
 }
@@ -2,15 +2,25 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"sync"
 	"time"
+
+	"channelspractice/internal/config"
 )
 
 func main() {
+	cfg := config.New("lesson_015")
+	numOfRequests := cfg.Int("requests", 10, "number of requests")
+	interval := cfg.Duration("interval", 200*time.Millisecond, "minimum delay between requests")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	var wg sync.WaitGroup
-	ticker := time.NewTicker(200 * time.Millisecond)
+	ticker := time.NewTicker(*interval)
 	defer ticker.Stop()
-	for id := range 10 {
+	for id := range *numOfRequests {
 		wg.Add(1)
 		<-ticker.C
 		go func() {
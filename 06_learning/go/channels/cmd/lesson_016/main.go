@@ -2,42 +2,80 @@ package main
 
 import (
 	"fmt"
+	"log/slog"
+	"os"
 	"sync"
+
+	"channelspractice/internal/config"
+	"channelspractice/internal/logx"
+	"channelspractice/internal/safe"
 )
 
 func main() {
+	// NOTE: stdout keeps the plain progress, the structured log goes to stderr
+	logx.FromEnvTo(os.Stderr)
+	cfg := config.New("lesson_016")
+	numOfWorkers := cfg.IntMin("workers", 3, 1, "number of workers")
+	numOfJobs := cfg.IntMin("jobs", 10, 0, "number of jobs")
+	buffer := cfg.IntMin("buffer", 10, 1, "buffer size of the jobs and results channels")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	var wg sync.WaitGroup
-	jobs := make(chan int, 10)
-	results := make(chan int, 10)
+	jobs := make(chan int, *buffer)
+	results := make(chan int, *buffer)
+	failed := make(chan error, *numOfJobs)
 
-	for workerID := range 3 {
+	for workerID := range *numOfWorkers {
 		wg.Add(1)
-		go worker(workerID, jobs, results, &wg)
+		go worker(workerID, jobs, results, failed, square, &wg)
 	}
 
-	for job := range 10 {
-		jobs <- job
-	}
+	// NOTE: send from a goroutine, more jobs than the buffer holds would block main before it reads a result
+	go func() {
+		defer close(jobs)
+		for job := range *numOfJobs {
+			jobs <- job
+		}
+	}()
 
 	go func() {
 		wg.Wait()
 		close(results)
+		close(failed)
 	}()
 
-	close(jobs)
-
 	var collected []int
 
 	for result := range results {
 		collected = append(collected, result)
 	}
 	fmt.Printf("results: %v\n", collected)
+	for err := range failed {
+		fmt.Printf("failed: %v\n", err)
+	}
+}
+
+func square(job int) int {
+	return job * job
 }
 
-func worker(workerID int, jobs <-chan int, results chan<- int, wg *sync.WaitGroup) {
+// worker runs process on every job. A job whose process panics lands on
+// failed instead of crashing the lesson.
+func worker(workerID int, jobs <-chan int, results chan<- int, failed chan<- error, process func(int) int, wg *sync.WaitGroup) {
 	defer wg.Done()
+	log := slog.With(logx.Worker(workerID))
 	for job := range jobs {
-		fmt.Printf("worker %d processing job %d\n", workerID, job)
-		results <- job * job
+		err := safe.Call(func() error {
+			fmt.Printf("worker %d processing job %d\n", workerID, job)
+			log.Info("processing", logx.Job(job))
+			results <- process(job)
+			return nil
+		})
+		if err != nil {
+			log.Error("job failed", logx.Job(job), "err", err)
+			failed <- fmt.Errorf("job %d, %w", job, err)
+		}
 	}
 }
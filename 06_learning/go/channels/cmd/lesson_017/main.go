@@ -1,25 +1,42 @@
+//go:build !chanx
+
 package main
 
 import (
 	"fmt"
+	"os"
 	"time"
+
+	"channelspractice/internal/config"
+	"channelspractice/internal/logx"
 )
 
 func main() {
+	cfg := config.New("lesson_017")
+	numOfUrgent := cfg.Int("urgent", 3, "urgent messages sent")
+	numOfNormal := cfg.Int("normal", 10, "normal messages sent")
+	urgentInterval := cfg.Duration("urgent-interval", 300*time.Millisecond, "delay between urgent messages")
+	normalInterval := cfg.Duration("normal-interval", 100*time.Millisecond, "delay between normal messages")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
+	// NOTE: stdout keeps the plain progress, the structured log goes to stderr
+	log := logx.FromEnvTo(os.Stderr)
 	urgent := make(chan int)
 	normal := make(chan int)
 
 	go func() {
-		for id := range 3 {
-			time.Sleep(300 * time.Millisecond)
+		for id := range *numOfUrgent {
+			time.Sleep(*urgentInterval)
 			urgent <- id
 		}
 		close(urgent)
 	}()
 
 	go func() {
-		for id := range 10 {
-			time.Sleep(100 * time.Millisecond)
+		for id := range *numOfNormal {
+			time.Sleep(*normalInterval)
 			normal <- id
 		}
 		close(normal)
@@ -30,7 +47,8 @@ func main() {
 
 	for {
 		if urgent == nil && normal == nil {
-			fmt.Printf("finished processing, exitting...\n")
+			fmt.Printf("finished processing, exiting...\n")
+			log.Info("finished processing, exiting", "urgent", urgentCount, "normal", normalCount)
 			return
 		}
 
@@ -41,7 +59,8 @@ func main() {
 				continue
 			}
 			urgentCount += 1
-			fmt.Printf("processing urgent message %d, porcessed: %d\n", msg, urgentCount)
+			fmt.Printf("processing urgent message %d, processed: %d\n", msg, urgentCount)
+			log.Info("processing message", "priority", "urgent", logx.Job(msg), "processed", urgentCount)
 		default:
 			select {
 			case msg, ok := <-normal:
@@ -50,14 +69,16 @@ func main() {
 					continue
 				}
 				normalCount += 1
-				fmt.Printf("processing normal message %d, porcessed: %d\n", msg, normalCount)
+				fmt.Printf("processing normal message %d, processed: %d\n", msg, normalCount)
+				log.Info("processing message", "priority", "normal", logx.Job(msg), "processed", normalCount)
 			case msg, ok := <-urgent:
 				if !ok {
 					urgent = nil
 					continue
 				}
 				urgentCount += 1
-				fmt.Printf("processing urgent message %d, porcessed: %d\n", msg, urgentCount)
+				fmt.Printf("processing urgent message %d, processed: %d\n", msg, urgentCount)
+				log.Info("processing message", "priority", "urgent", logx.Job(msg), "processed", urgentCount)
 			}
 		}
 	}
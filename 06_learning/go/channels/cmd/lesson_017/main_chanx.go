@@ -0,0 +1,89 @@
+//go:build chanx
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"channelspractice/internal/chanx"
+	"channelspractice/internal/config"
+	"channelspractice/internal/logx"
+)
+
+func main() {
+	cfg := config.New("lesson_017")
+	numOfUrgent := cfg.Int("urgent", 3, "urgent messages sent")
+	numOfNormal := cfg.Int("normal", 10, "normal messages sent")
+	urgentInterval := cfg.Duration("urgent-interval", 300*time.Millisecond, "delay between urgent messages")
+	normalInterval := cfg.Duration("normal-interval", 100*time.Millisecond, "delay between normal messages")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
+	// NOTE: stdout keeps the plain progress, the structured log goes to stderr
+	log := logx.FromEnvTo(os.Stderr)
+	urgent := chanx.Make[int]("urgent", 0)
+	normal := chanx.Make[int]("normal", 0)
+
+	go func() {
+		for id := range *numOfUrgent {
+			time.Sleep(*urgentInterval)
+			urgent.Send(id)
+		}
+		urgent.Close()
+	}()
+
+	go func() {
+		for id := range *numOfNormal {
+			time.Sleep(*normalInterval)
+			normal.Send(id)
+		}
+		normal.Close()
+	}()
+
+	urgentCount := 0
+	normalCount := 0
+
+	for {
+		if urgent == nil && normal == nil {
+			fmt.Printf("finished processing, exiting...\n")
+			log.Info("finished processing, exiting", "urgent", urgentCount, "normal", normalCount)
+			return
+		}
+
+		select {
+		case msg, ok := <-urgent.C():
+			urgent.Received(msg, ok)
+			if !ok {
+				urgent = nil
+				continue
+			}
+			urgentCount += 1
+			fmt.Printf("processing urgent message %d, processed: %d\n", msg, urgentCount)
+			log.Info("processing message", "priority", "urgent", logx.Job(msg), "processed", urgentCount)
+		default:
+			select {
+			case msg, ok := <-normal.C():
+				normal.Received(msg, ok)
+				if !ok {
+					normal = nil
+					continue
+				}
+				normalCount += 1
+				fmt.Printf("processing normal message %d, processed: %d\n", msg, normalCount)
+				log.Info("processing message", "priority", "normal", logx.Job(msg), "processed", normalCount)
+			case msg, ok := <-urgent.C():
+				urgent.Received(msg, ok)
+				if !ok {
+					urgent = nil
+					continue
+				}
+				urgentCount += 1
+				fmt.Printf("processing urgent message %d, processed: %d\n", msg, urgentCount)
+				log.Info("processing message", "priority", "urgent", logx.Job(msg), "processed", urgentCount)
+			}
+		}
+	}
+}
@@ -3,29 +3,49 @@ package main
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"golang.org/x/sync/errgroup"
+
+	"channelspractice/internal/config"
+	"channelspractice/internal/logx"
+	"channelspractice/internal/safe"
 )
 
 func main() {
+	// NOTE: stdout keeps the plain progress, the structured log goes to stderr
+	log := logx.FromEnvTo(os.Stderr)
+	cfg := config.New("lesson_018")
+	numOfWorkers := cfg.IntMin("workers", 3, 1, "number of workers")
+	numOfJobs := cfg.IntMin("jobs", 10, 0, "number of jobs")
+	failJob := cfg.Int("fail-job", 7, "job that fails the group, -1 for none")
+	work := cfg.Duration("work", 1*time.Second, "how long each job takes")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	signalCtx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 	g, ctx := errgroup.WithContext(signalCtx)
 
 	jobs := make(chan int)
 
-	for workerID := range 3 {
-		g.Go(func() error {
-			return worker(ctx, workerID, jobs)
-		})
+	process := func(job int) error {
+		if job == *failJob {
+			return fmt.Errorf("job %d failed", job)
+		}
+		time.Sleep(*work)
+		return nil
 	}
+	startWorkers(ctx, g, *numOfWorkers, jobs, process)
 
 	g.Go(func() error {
 		defer close(jobs)
-		for job := range 10 {
+		for job := range *numOfJobs {
 			select {
 			case <-ctx.Done():
 				return nil
@@ -39,32 +59,49 @@ func main() {
 
 	if signalCtx.Err() != nil && err == nil {
 		fmt.Printf("detected termination signal, shut down process\n")
+		log.Info("detected termination signal, shut down process")
 		return
 	}
 
 	if err != nil {
 		fmt.Printf("shutdown on worker error: %v\n", err)
+		log.Error("shutdown on worker error", "err", err)
 		return
 	}
 
 	fmt.Printf("finished processing jobs\n")
+	log.Info("finished processing jobs")
+}
+
+// startWorkers starts n workers in g. A panicking worker fails the group
+// like a returned error instead of crashing the process.
+func startWorkers(ctx context.Context, g *errgroup.Group, n int, jobs <-chan int, process func(int) error) {
+	for workerID := range n {
+		g.Go(safe.Func(func() error {
+			return worker(ctx, workerID, jobs, process)
+		}))
+	}
 }
 
-func worker(ctx context.Context, workerID int, jobs <-chan int) error {
+func worker(ctx context.Context, workerID int, jobs <-chan int, process func(int) error) error {
+	log := slog.With(logx.Worker(workerID))
 	for {
 		select {
 		case <-ctx.Done():
-			fmt.Printf("worker %d shutting down\n", workerID)
+			fmt.Printf("worker %d: shutting down\n", workerID)
+			log.Info("shutting down")
 			return nil
 		case job, ok := <-jobs:
 			if !ok {
 				return nil // jobs channel closed
 			}
-			if job == 7 {
-				return fmt.Errorf("job %d failed", job)
+			fmt.Printf("worker %d: processing job %d\n", workerID, job)
+			log.Info("processing", logx.Job(job))
+			if err := process(job); err != nil {
+				fmt.Printf("worker %d: encountered error\n", workerID)
+				log.Error("job failed", logx.Job(job), "err", err)
+				return err
 			}
-			fmt.Printf("worker %d processing job %d\n", workerID, job)
-			time.Sleep(1 * time.Second)
 		}
 	}
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/sync/errgroup"
+
+	"channelspractice/internal/safe"
+)
+
+// TestWorkerPanicFailsGroup runs the lesson's workers with a job that
+// panics: the group fails with a *safe.PanicError carrying its stack, and
+// the other workers shut down.
+func TestWorkerPanicFailsGroup(t *testing.T) {
+	g, ctx := errgroup.WithContext(context.Background())
+	jobs := make(chan int)
+	startWorkers(ctx, g, 3, jobs, func(job int) error {
+		if job == 3 {
+			panic("bad job 3")
+		}
+		return nil
+	})
+	g.Go(func() error {
+		defer close(jobs)
+		for job := range 10 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case jobs <- job:
+			}
+		}
+		return nil
+	})
+
+	err := g.Wait()
+	var pe *safe.PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Wait = %v, want a *safe.PanicError", err)
+	}
+	if pe.Value != "bad job 3" {
+		t.Errorf("panic value = %v, want bad job 3", pe.Value)
+	}
+	if !strings.Contains(string(pe.Stack), "TestWorkerPanicFailsGroup") {
+		t.Errorf("stack does not show where the panic happened:\n%s", pe.Stack)
+	}
+}
@@ -1,23 +1,41 @@
+//go:build !chanx
+
 package main
 
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 	"time"
+
+	"channelspractice/internal/config"
+	"channelspractice/internal/safe"
 )
 
 func main() {
+	cfg := config.New("lesson_019")
+	numbers := cfg.Int("numbers", 11, "numbers generated, from 0")
+	invalid := cfg.Int("invalid", 6, "number rejected by transform, -1 for none")
+	delay := cfg.Duration("delay", 100*time.Millisecond, "delay of every stage per number")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
-	nums := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	nums := make([]int, *numbers)
+	for i := range nums {
+		nums[i] = i
+	}
 	genChan := generator(ctx, nums)
-	transChan, transErrChan := transform(ctx, genChan)
-	doneChan, saveErrChan := save(ctx, transChan)
+	transChan, transErrChan := transform(ctx, genChan, double(*invalid), *delay)
+	doneChan, saveErrChan := save(ctx, transChan, *delay)
 	mergedErrChan := mergeErrorChannels(ctx, transErrChan, saveErrChan)
 
-	for {
+	// NOTE: wait for the error channels too, save may finish before the error that stopped it is reported
+	for mergedErrChan != nil || doneChan != nil {
 		select {
 		case err, ok := <-mergedErrChan:
 			if !ok {
@@ -27,10 +45,10 @@ func main() {
 			fmt.Printf("error: %v\n", err)
 			cancel()
 		case <-doneChan:
-			fmt.Printf("finished processing\n")
-			return
+			doneChan = nil
 		}
 	}
+	fmt.Printf("finished processing\n")
 }
 
 func mergeErrorChannels(ctx context.Context, errChans ...<-chan error) <-chan error {
@@ -80,50 +98,72 @@ func generator(ctx context.Context, nums []int) <-chan int {
 	return outChan
 }
 
-func transform(ctx context.Context, inChan <-chan int) (<-chan int, <-chan error) {
+// double is the work of transform: it doubles a number and rejects
+// invalid.
+func double(invalid int) func(int) (int, error) {
+	return func(num int) (int, error) {
+		if num == invalid {
+			return 0, fmt.Errorf("number %d is invalid", num)
+		}
+		return num * 2, nil
+	}
+}
+
+func transform(ctx context.Context, inChan <-chan int, apply func(int) (int, error), delay time.Duration) (<-chan int, <-chan error) {
 	outChan := make(chan int)
 	errChan := make(chan error)
 	go func() {
 		defer close(errChan)
 		defer close(outChan)
-		for {
-			time.Sleep(100 * time.Millisecond)
-			select {
-			case <-ctx.Done():
-				return
-			case num, ok := <-inChan:
-				if !ok {
-					return
-				}
-				if num == 6 {
-					errChan <- fmt.Errorf("number %d is invalid", num)
-					return
+		// NOTE: a panic in the stage is reported on its error channel like any other error
+		err := safe.Call(func() error {
+			for {
+				time.Sleep(delay)
+				select {
+				case <-ctx.Done():
+					return nil
+				case num, ok := <-inChan:
+					if !ok {
+						return nil
+					}
+					out, err := apply(num)
+					if err != nil {
+						return err
+					}
+					outChan <- out
 				}
-				outChan <- num * 2
 			}
+		})
+		if err != nil {
+			errChan <- err
 		}
 	}()
 	return outChan, errChan
 
 }
 
-func save(ctx context.Context, inChan <-chan int) (<-chan struct{}, <-chan error) {
+func save(ctx context.Context, inChan <-chan int, delay time.Duration) (<-chan struct{}, <-chan error) {
 	doneChan := make(chan struct{})
 	errChan := make(chan error)
 	go func() {
 		defer close(errChan)
 		defer close(doneChan)
-		for {
-			time.Sleep(100 * time.Millisecond)
-			select {
-			case <-ctx.Done():
-				return
-			case num, ok := <-inChan:
-				if !ok {
-					return
+		err := safe.Call(func() error {
+			for {
+				time.Sleep(delay)
+				select {
+				case <-ctx.Done():
+					return nil
+				case num, ok := <-inChan:
+					if !ok {
+						return nil
+					}
+					fmt.Printf("saved %d\n", num)
 				}
-				fmt.Printf("saved %d\n", num)
 			}
+		})
+		if err != nil {
+			errChan <- err
 		}
 	}()
 	return doneChan, errChan
@@ -0,0 +1,159 @@
+//go:build chanx
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"channelspractice/internal/chanx"
+	"channelspractice/internal/config"
+	"channelspractice/internal/safe"
+)
+
+func main() {
+	cfg := config.New("lesson_019")
+	numbers := cfg.Int("numbers", 11, "numbers generated, from 0")
+	invalid := cfg.Int("invalid", 6, "number rejected by transform, -1 for none")
+	delay := cfg.Duration("delay", 100*time.Millisecond, "delay of every stage per number")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	nums := make([]int, *numbers)
+	for i := range nums {
+		nums[i] = i
+	}
+	genChan := generator(ctx, nums)
+	transChan, transErrChan := transform(ctx, genChan, double(*invalid), *delay)
+	doneChan, saveErrChan := save(ctx, transChan, *delay)
+	mergedErrChan := mergeErrorChannels(ctx, transErrChan, saveErrChan)
+
+	// NOTE: wait for the error channels too, save may finish before the error that stopped it is reported
+	for mergedErrChan != nil || doneChan != nil {
+		select {
+		case err, ok := <-mergedErrChan.C():
+			mergedErrChan.Received(err, ok)
+			if !ok {
+				mergedErrChan = nil
+				continue
+			}
+			fmt.Printf("error: %v\n", err)
+			cancel()
+		case v, ok := <-doneChan.C():
+			doneChan.Received(v, ok)
+			doneChan = nil
+		}
+	}
+	fmt.Printf("finished processing\n")
+}
+
+func mergeErrorChannels(ctx context.Context, errChans ...*chanx.Chan[error]) *chanx.Chan[error] {
+	merged := chanx.Make[error]("merged errors", 0)
+
+	var wg sync.WaitGroup
+	wg.Add(len(errChans))
+
+	for _, errChan := range errChans {
+		go func(e *chanx.Chan[error]) {
+			defer wg.Done()
+			for {
+				err, ok, ctxErr := e.RecvCtx(ctx)
+				if ctxErr != nil || !ok {
+					return
+				}
+				merged.Send(err)
+				return
+			}
+		}(errChan)
+	}
+
+	go func() {
+		defer merged.Close()
+		wg.Wait()
+	}()
+
+	return merged
+}
+
+func generator(ctx context.Context, nums []int) *chanx.Chan[int] {
+	outChan := chanx.Make[int]("generator", 0)
+	go func() {
+		defer outChan.Close()
+		for _, num := range nums {
+			if err := outChan.SendCtx(ctx, num); err != nil {
+				return
+			}
+		}
+	}()
+	return outChan
+}
+
+// double is the work of transform: it doubles a number and rejects
+// invalid.
+func double(invalid int) func(int) (int, error) {
+	return func(num int) (int, error) {
+		if num == invalid {
+			return 0, fmt.Errorf("number %d is invalid", num)
+		}
+		return num * 2, nil
+	}
+}
+
+func transform(ctx context.Context, inChan *chanx.Chan[int], apply func(int) (int, error), delay time.Duration) (*chanx.Chan[int], *chanx.Chan[error]) {
+	outChan := chanx.Make[int]("transform", 0)
+	errChan := chanx.Make[error]("transform errors", 0)
+	go func() {
+		defer errChan.Close()
+		defer outChan.Close()
+		// NOTE: a panic in the stage is reported on its error channel like any other error
+		err := safe.Call(func() error {
+			for {
+				time.Sleep(delay)
+				num, ok, err := inChan.RecvCtx(ctx)
+				if err != nil || !ok {
+					return nil
+				}
+				out, err := apply(num)
+				if err != nil {
+					return err
+				}
+				outChan.Send(out)
+			}
+		})
+		if err != nil {
+			errChan.Send(err)
+		}
+	}()
+	return outChan, errChan
+
+}
+
+func save(ctx context.Context, inChan *chanx.Chan[int], delay time.Duration) (*chanx.Chan[struct{}], *chanx.Chan[error]) {
+	doneChan := chanx.Make[struct{}]("done", 0)
+	errChan := chanx.Make[error]("save errors", 0)
+	go func() {
+		defer errChan.Close()
+		defer doneChan.Close()
+		err := safe.Call(func() error {
+			for {
+				time.Sleep(delay)
+				num, ok, err := inChan.RecvCtx(ctx)
+				if err != nil || !ok {
+					return nil
+				}
+				fmt.Printf("saved %d\n", num)
+			}
+		})
+		if err != nil {
+			errChan.Send(err)
+		}
+	}()
+	return doneChan, errChan
+}
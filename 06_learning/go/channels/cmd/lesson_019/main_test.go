@@ -0,0 +1,63 @@
+//go:build !chanx
+
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"channelspractice/internal/safe"
+)
+
+// TestTransformPanic runs the lesson's transform stage with a number that
+// panics: the panic comes out of the stage's error channel as a
+// *safe.PanicError carrying its stack, and the stage closes its output.
+func TestTransformPanic(t *testing.T) {
+	in := make(chan int, 5)
+	for num := range 5 {
+		in <- num
+	}
+	close(in)
+	out, errChan := transform(context.Background(), in, func(num int) (int, error) {
+		if num == 2 {
+			panic("bad number 2")
+		}
+		return num * 2, nil
+	}, 0)
+
+	var got []int
+	var err error
+	for out != nil || errChan != nil {
+		select {
+		case v, ok := <-out:
+			if !ok {
+				out = nil
+				continue
+			}
+			got = append(got, v)
+		case e, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			err = e
+		}
+	}
+
+	if !slices.Equal(got, []int{0, 2}) {
+		t.Errorf("transform sent %v, want [0 2]", got)
+	}
+	var pe *safe.PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("error channel got %v, want a *safe.PanicError", err)
+	}
+	if pe.Value != "bad number 2" {
+		t.Errorf("panic value = %v, want bad number 2", pe.Value)
+	}
+	if !strings.Contains(string(pe.Stack), "TestTransformPanic") {
+		t.Errorf("stack does not show where the panic happened:\n%s", pe.Stack)
+	}
+}
@@ -1,82 +1,153 @@
+//go:build !chanx
+
 package main
 
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"golang.org/x/sync/errgroup"
+
+	"channelspractice/internal/checkpoint"
+	"channelspractice/internal/config"
+	"channelspractice/internal/logx"
+	"channelspractice/internal/safe"
 )
 
+// item carries the position of a number in nums through the stages, so
+// save can commit how far the pipeline got. ctx carries the item's logger,
+// tagged with a correlation ID, across the channel hops.
+type item struct {
+	ctx    context.Context
+	offset int
+	num    int
+}
+
 func main() {
+	// NOTE: stdout keeps the plain progress, the structured trace of every item goes to stderr
+	logx.FromEnvTo(os.Stderr)
+	cfg := config.New("lesson_019b")
+	numbers := cfg.Int("numbers", 10, "numbers generated, from 0")
+	invalid := cfg.Int("invalid", 6, "number rejected by transform, -1 for none")
+	delay := cfg.Duration("delay", 100*time.Millisecond, "delay of the generator and transform per number")
+	checkpointFile := cfg.String("checkpoint", "lesson_019b.checkpoint", "file progress is committed to, an interrupted run resumes after the last saved item")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	signalCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
+	// NOTE: every stage runs under safe.Func, a panic fails the pipeline through the errgroup like a returned error
 	g, ctx := errgroup.WithContext(signalCtx)
 
-	nums := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
-	generatorChan := generator(ctx, nums, g)
-	transformChan := transform(ctx, generatorChan, g)
-	save(ctx, transformChan, g)
+	cp, err := checkpoint.Open(*checkpointFile)
+	if err != nil {
+		fmt.Printf("checkpoint error: %v\n", err)
+		return
+	}
+	start := cp.Offset("nums")
+	if start > 0 {
+		fmt.Printf("resuming from offset %d, delete %s to start over\n", start, *checkpointFile)
+	}
 
-	if err := g.Wait(); err != nil {
+	nums := make([]int, *numbers)
+	for i := range nums {
+		nums[i] = i
+	}
+	generatorChan := generator(ctx, nums, start, *delay, g)
+	transformChan := transform(ctx, generatorChan, double(*invalid), *delay, g)
+	save(ctx, transformChan, cp, g)
+
+	err = g.Wait()
+	// NOTE: only an interrupted run resumes, after an error it would only run into the same error again
+	if signalCtx.Err() != nil {
+		fmt.Printf("interrupted, progress saved in %s\n", *checkpointFile)
+		return
+	}
+	if err := cp.Reset(); err != nil {
+		fmt.Printf("checkpoint error: %v\n", err)
+	}
+	if err != nil {
 		fmt.Printf("pipeline error: %v\n", err)
 		return
 	}
-
 	fmt.Printf("successfully finished processing\n")
 }
 
-func generator(ctx context.Context, nums []int, g *errgroup.Group) <-chan int {
-	outChan := make(chan int)
-	g.Go(func() error {
+func generator(ctx context.Context, nums []int, start int, delay time.Duration, g *errgroup.Group) <-chan item {
+	outChan := make(chan item)
+	g.Go(safe.Func(func() error {
 		defer close(outChan)
-		for _, num := range nums {
-			time.Sleep(100 * time.Millisecond)
+		for offset := start; offset < len(nums); offset++ {
+			time.Sleep(delay)
+			// NOTE: every item gets its own correlation ID, the stages log it through it.ctx
+			itemCtx := logx.WithCorrelationID(ctx, "")
+			logx.From(itemCtx).Info("generated", logx.Stage("generator"), logx.Job(offset), "num", nums[offset])
 			select {
 			case <-ctx.Done():
 				return nil
-			case outChan <- num:
+			case outChan <- item{itemCtx, offset, nums[offset]}:
 			}
 		}
 		return nil
-	})
+	}))
 	return outChan
 }
 
-func transform(ctx context.Context, inChan <-chan int, g *errgroup.Group) <-chan int {
-	outChan := make(chan int)
+// double is the work of transform: it doubles a number and rejects
+// invalid.
+func double(invalid int) func(int) (int, error) {
+	return func(num int) (int, error) {
+		if num == invalid {
+			return 0, fmt.Errorf("number %d is invalid", num)
+		}
+		return num * 2, nil
+	}
+}
+
+func transform(ctx context.Context, inChan <-chan item, apply func(int) (int, error), delay time.Duration, g *errgroup.Group) <-chan item {
+	outChan := make(chan item)
 
-	g.Go(func() error {
+	g.Go(safe.Func(func() error {
 		defer close(outChan)
-		for num := range inChan {
-			if num == 6 {
-				return fmt.Errorf("transform error: number %d is invalid", num)
+		for it := range inChan {
+			out, err := apply(it.num)
+			if err != nil {
+				return fmt.Errorf("transform error: %w, correlation id %s", err, logx.CorrelationID(it.ctx))
 			}
-			time.Sleep(100 * time.Millisecond)
+			time.Sleep(delay)
+			logx.From(it.ctx).Info("transformed", logx.Stage("transform"), logx.Job(it.offset), "num", out)
 			select {
 			case <-ctx.Done():
 				return nil
-			case outChan <- num * 2:
+			case outChan <- item{it.ctx, it.offset, out}:
 			}
 		}
 		return nil
-	})
+	}))
 
 	return outChan
 }
 
-func save(ctx context.Context, inChan <-chan int, g *errgroup.Group) {
-	g.Go(func() error {
-		for num := range inChan {
+func save(ctx context.Context, inChan <-chan item, cp *checkpoint.File, g *errgroup.Group) {
+	g.Go(safe.Func(func() error {
+		for it := range inChan {
 			select {
 			case <-ctx.Done():
 				return nil
 			default:
-				fmt.Printf("saved: %d\n", num)
+				fmt.Printf("saved: %d\n", it.num)
+				logx.From(it.ctx).Info("saved", logx.Stage("save"), logx.Job(it.offset), "num", it.num)
+				// NOTE: commit only after the item is saved, a crash in between saves it twice but never loses it
+				if err := cp.Commit("nums", it.offset+1); err != nil {
+					return err
+				}
 			}
 		}
 		return nil
-	})
+	}))
 }
@@ -0,0 +1,153 @@
+//go:build chanx
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"channelspractice/internal/chanx"
+	"channelspractice/internal/checkpoint"
+	"channelspractice/internal/config"
+	"channelspractice/internal/logx"
+	"channelspractice/internal/safe"
+	"channelspractice/internal/stepper"
+)
+
+// item carries the position of a number in nums through the stages, so
+// save can commit how far the pipeline got. ctx carries the item's logger,
+// tagged with a correlation ID, across the channel hops.
+type item struct {
+	ctx    context.Context
+	offset int
+	num    int
+}
+
+func main() {
+	// NOTE: stdout keeps the plain progress, the structured trace of every item goes to stderr
+	logx.FromEnvTo(os.Stderr)
+	cfg := config.New("lesson_019b")
+	numbers := cfg.Int("numbers", 10, "numbers generated, from 0")
+	invalid := cfg.Int("invalid", 6, "number rejected by transform, -1 for none")
+	delay := cfg.Duration("delay", 100*time.Millisecond, "delay of the generator and transform per number")
+	checkpointFile := cfg.String("checkpoint", "lesson_019b.checkpoint", "file progress is committed to, an interrupted run resumes after the last saved item")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
+	signalCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	// NOTE: CHANX_STEP=1 (or lessons step 19b) pauses before every channel operation, see internal/stepper
+	defer stepper.FromEnv(stop)()
+	// NOTE: every stage runs under safe.Func, a panic fails the pipeline through the errgroup like a returned error
+	g, ctx := errgroup.WithContext(signalCtx)
+
+	cp, err := checkpoint.Open(*checkpointFile)
+	if err != nil {
+		fmt.Printf("checkpoint error: %v\n", err)
+		return
+	}
+	start := cp.Offset("nums")
+	if start > 0 {
+		fmt.Printf("resuming from offset %d, delete %s to start over\n", start, *checkpointFile)
+	}
+
+	nums := make([]int, *numbers)
+	for i := range nums {
+		nums[i] = i
+	}
+	generatorChan := generator(ctx, nums, start, *delay, g)
+	transformChan := transform(ctx, generatorChan, double(*invalid), *delay, g)
+	save(ctx, transformChan, cp, g)
+
+	err = g.Wait()
+	// NOTE: only an interrupted run resumes, after an error it would only run into the same error again
+	if signalCtx.Err() != nil {
+		fmt.Printf("interrupted, progress saved in %s\n", *checkpointFile)
+		return
+	}
+	if err := cp.Reset(); err != nil {
+		fmt.Printf("checkpoint error: %v\n", err)
+	}
+	if err != nil {
+		fmt.Printf("pipeline error: %v\n", err)
+		return
+	}
+	fmt.Printf("successfully finished processing\n")
+}
+
+func generator(ctx context.Context, nums []int, start int, delay time.Duration, g *errgroup.Group) chanx.Receiver[item] {
+	outChan := chanx.Make[item]("generated", 0)
+	g.Go(safe.Func(chanx.Named("generator", func() error {
+		defer outChan.Close()
+		for offset := start; offset < len(nums); offset++ {
+			time.Sleep(delay)
+			// NOTE: every item gets its own correlation ID, the stages log it through it.ctx
+			itemCtx := logx.WithCorrelationID(ctx, "")
+			logx.From(itemCtx).Info("generated", logx.Stage("generator"), logx.Job(offset), "num", nums[offset])
+			if err := outChan.SendCtx(ctx, item{itemCtx, offset, nums[offset]}); err != nil {
+				return nil
+			}
+		}
+		return nil
+	})))
+	return outChan.Receiver()
+}
+
+// double is the work of transform: it doubles a number and rejects
+// invalid.
+func double(invalid int) func(int) (int, error) {
+	return func(num int) (int, error) {
+		if num == invalid {
+			return 0, fmt.Errorf("number %d is invalid", num)
+		}
+		return num * 2, nil
+	}
+}
+
+func transform(ctx context.Context, inChan chanx.Receiver[item], apply func(int) (int, error), delay time.Duration, g *errgroup.Group) chanx.Receiver[item] {
+	outChan := chanx.Make[item]("transformed", 0)
+
+	g.Go(safe.Func(chanx.Named("transform", func() error {
+		defer outChan.Close()
+		for it := range inChan.All() {
+			out, err := apply(it.num)
+			if err != nil {
+				return fmt.Errorf("transform error: %w, correlation id %s", err, logx.CorrelationID(it.ctx))
+			}
+			time.Sleep(delay)
+			logx.From(it.ctx).Info("transformed", logx.Stage("transform"), logx.Job(it.offset), "num", out)
+			if err := outChan.SendCtx(ctx, item{it.ctx, it.offset, out}); err != nil {
+				return nil
+			}
+		}
+		return nil
+	})))
+
+	return outChan.Receiver()
+}
+
+func save(ctx context.Context, inChan chanx.Receiver[item], cp *checkpoint.File, g *errgroup.Group) {
+	g.Go(safe.Func(chanx.Named("save", func() error {
+		for it := range inChan.All() {
+			select {
+			case <-ctx.Done():
+				return nil
+			default:
+				fmt.Printf("saved: %d\n", it.num)
+				logx.From(it.ctx).Info("saved", logx.Stage("save"), logx.Job(it.offset), "num", it.num)
+				// NOTE: commit only after the item is saved, a crash in between saves it twice but never loses it
+				if err := cp.Commit("nums", it.offset+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})))
+}
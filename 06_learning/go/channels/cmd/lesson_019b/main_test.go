@@ -0,0 +1,46 @@
+//go:build !chanx
+
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/sync/errgroup"
+
+	"channelspractice/internal/safe"
+)
+
+// TestTransformPanicFailsGroup runs the lesson's transform stage with a
+// number that panics: the pipeline's errgroup fails with a
+// *safe.PanicError carrying its stack.
+func TestTransformPanicFailsGroup(t *testing.T) {
+	g, ctx := errgroup.WithContext(context.Background())
+	in := make(chan item, 5)
+	for num := range 5 {
+		in <- item{context.Background(), num, num}
+	}
+	close(in)
+	out := transform(ctx, in, func(num int) (int, error) {
+		if num == 2 {
+			panic("bad number 2")
+		}
+		return num * 2, nil
+	}, 0, g)
+	for range out {
+	}
+
+	err := g.Wait()
+	var pe *safe.PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Wait = %v, want a *safe.PanicError", err)
+	}
+	if pe.Value != "bad number 2" {
+		t.Errorf("panic value = %v, want bad number 2", pe.Value)
+	}
+	if !strings.Contains(string(pe.Stack), "TestTransformPanicFailsGroup") {
+		t.Errorf("stack does not show where the panic happened:\n%s", pe.Stack)
+	}
+}
@@ -1,22 +1,36 @@
+//go:build !chanx
+
 package main
 
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 	"time"
+
+	"channelspractice/internal/config"
 )
 
 func main() {
+	cfg := config.New("lesson_020")
+	numOfGenerators := cfg.Int("generators", 3, "number of generators merged")
+	numbers := cfg.Int("numbers", 10, "numbers sent by each generator, from 0")
+	delay := cfg.Duration("delay", 50*time.Millisecond, "delay before each number")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	ctx, close := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
 	defer close()
-	g1 := generator(ctx)
-	g2 := generator(ctx)
-	g3 := generator(ctx)
+	var gens []<-chan int
+	for range *numOfGenerators {
+		gens = append(gens, orDone(ctx, generator(ctx, *numbers, *delay)))
+	}
 
-	merged := merge(ctx, orDone(ctx, g1), orDone(ctx, g2), orDone(ctx, g3))
+	merged := merge(ctx, gens...)
 
 	for n := range merged {
 		fmt.Printf("received %d\n", n)
@@ -25,13 +39,12 @@ func main() {
 	fmt.Printf("done\n")
 }
 
-func generator(ctx context.Context) <-chan int {
+func generator(ctx context.Context, numbers int, delay time.Duration) <-chan int {
 	out := make(chan int)
 	go func() {
 		defer close(out)
-		nums := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
-		for _, num := range nums {
-			time.Sleep(50 * time.Millisecond)
+		for num := range numbers {
+			time.Sleep(delay)
 			select {
 			case <-ctx.Done():
 				return
@@ -0,0 +1,103 @@
+//go:build chanx
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"channelspractice/internal/chanx"
+	"channelspractice/internal/config"
+	"channelspractice/internal/stepper"
+)
+
+func main() {
+	cfg := config.New("lesson_020")
+	numOfGenerators := cfg.Int("generators", 3, "number of generators merged")
+	numbers := cfg.Int("numbers", 10, "numbers sent by each generator, from 0")
+	delay := cfg.Duration("delay", 50*time.Millisecond, "delay before each number")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
+	ctx, close := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer close()
+	// NOTE: CHANX_STEP=1 (or lessons step 20) pauses before every channel operation, see internal/stepper
+	defer stepper.FromEnv(close)()
+	var gens []chanx.Receiver[int]
+	for id := range *numOfGenerators {
+		gens = append(gens, orDone(ctx, generator(ctx, id+1, *numbers, *delay)))
+	}
+
+	merged := merge(ctx, gens...)
+
+	for n := range merged.All() {
+		fmt.Printf("received %d\n", n)
+	}
+
+	fmt.Printf("done\n")
+}
+
+func generator(ctx context.Context, id, numbers int, delay time.Duration) chanx.Receiver[int] {
+	name := "generator " + strconv.Itoa(id)
+	out := chanx.Make[int](name, 0)
+	chanx.Go(name, func() {
+		defer out.Close()
+		for num := range numbers {
+			time.Sleep(delay)
+			if err := out.SendCtx(ctx, num); err != nil {
+				return
+			}
+		}
+	})
+	return out.Receiver()
+}
+
+func orDone[T any](ctx context.Context, ch chanx.Receiver[T]) chanx.Receiver[T] {
+	name := "orDone(" + ch.String() + ")"
+	out := chanx.Make[T](name, 0)
+	chanx.Go(name, func() {
+		defer out.Close()
+		for {
+			val, ok, err := ch.RecvCtx(ctx)
+			if err != nil || !ok {
+				return
+			}
+			if err := out.SendCtx(ctx, val); err != nil {
+				return
+			}
+		}
+	})
+	return out.Receiver()
+}
+
+func merge[T any](ctx context.Context, chs ...chanx.Receiver[T]) chanx.Receiver[T] {
+	merged := chanx.Make[T]("merged", 0)
+	var wg sync.WaitGroup
+	wg.Add(len(chs))
+	for _, ch := range chs {
+		chanx.Go("merge "+ch.String(), func() {
+			defer wg.Done()
+			for {
+				val, ok, err := ch.RecvCtx(ctx)
+				if err != nil || !ok {
+					return
+				}
+				if err := merged.SendCtx(ctx, val); err != nil {
+					return
+				}
+			}
+		})
+	}
+	chanx.Go("merge closer", func() {
+		defer merged.Close()
+		wg.Wait()
+	})
+	return merged.Receiver()
+}
@@ -3,17 +3,28 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 	"sync"
 	"time"
+
+	"channelspractice/internal/config"
 )
 
 func main() {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	cfg := config.New("lesson_021")
+	timeout := cfg.Duration("timeout", 2*time.Second, "deadline of the context")
+	numbers := cfg.Int("numbers", 50, "numbers sent into the tee")
+	slow := cfg.Duration("slow", 100*time.Millisecond, "delay of the slow consumer per number")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
 	defer cancel()
 	g0 := make(chan int)
 	g1, g2 := tee(ctx, g0)
 	go func() {
-		for n := range 50 {
+		for n := range *numbers {
 			g0 <- n
 		}
 	}()
@@ -31,7 +42,7 @@ func main() {
 	go func() {
 		defer wg.Done()
 		for n := range g2 {
-			time.Sleep(100 * time.Millisecond)
+			time.Sleep(*slow)
 			fmt.Printf("slow %d\n", n)
 		}
 	}()
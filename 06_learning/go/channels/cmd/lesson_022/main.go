@@ -1,22 +1,35 @@
+//go:build !chanx
+
 package main
 
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 	"time"
+
+	"channelspractice/internal/config"
 )
 
 func main() {
+	cfg := config.New("lesson_022")
+	numOfWorkers := cfg.IntMin("workers", 3, 1, "number of workers bridged")
+	count := cfg.Int("count", 5, "numbers sent by each worker")
+	delay := cfg.Duration("delay", 100*time.Millisecond, "delay before each number")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 	chanOfChans := make(chan (<-chan int))
 	go func() {
 		defer close(chanOfChans)
-		for id := range 3 {
-			chanOfChans <- worker(id, ctx)
+		for id := range *numOfWorkers {
+			chanOfChans <- worker(id, *count, *delay, ctx)
 		}
 	}()
 	output := bridge(ctx, chanOfChans)
@@ -57,12 +70,12 @@ func bridge[T any](ctx context.Context, chanOfChans <-chan (<-chan T)) <-chan T
 	return out
 }
 
-func worker(id int, ctx context.Context) <-chan int {
+func worker(id, count int, delay time.Duration, ctx context.Context) <-chan int {
 	out := make(chan int)
 	go func() {
 		defer close(out)
-		for n := range 5 {
-			time.Sleep(100 * time.Millisecond)
+		for n := range count {
+			time.Sleep(delay)
 			fmt.Printf("worker %d: %d\n", id, n)
 			select {
 			case <-ctx.Done():
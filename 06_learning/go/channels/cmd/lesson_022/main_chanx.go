@@ -0,0 +1,86 @@
+//go:build chanx
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"channelspractice/internal/chanx"
+	"channelspractice/internal/config"
+	"channelspractice/internal/recorder"
+)
+
+func main() {
+	defer recorder.FromEnv()()
+	chanx.Label("main")
+	cfg := config.New("lesson_022")
+	numOfWorkers := cfg.IntMin("workers", 3, 1, "number of workers bridged")
+	count := cfg.Int("count", 5, "numbers sent by each worker")
+	delay := cfg.Duration("delay", 100*time.Millisecond, "delay before each number")
+	if err := cfg.Parse(); err != nil {
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	chanOfChans := chanx.Make[chanx.Receiver[int]]("chanOfChans", 0)
+	chanx.Go("spawner", func() {
+		defer chanOfChans.Close()
+		for id := range *numOfWorkers {
+			chanOfChans.Send(worker(id, *count, *delay, ctx))
+		}
+	})
+	output := bridge(ctx, chanOfChans.Receiver())
+	for num := range output.All() {
+		fmt.Printf("received: %d\n", num)
+	}
+	fmt.Printf("done\n")
+}
+
+func bridge[T any](ctx context.Context, chanOfChans chanx.Receiver[chanx.Receiver[T]]) chanx.Receiver[T] {
+	out := chanx.Make[T]("bridge", 0)
+	chanx.Go("bridge", func() {
+		defer out.Close()
+		var wg sync.WaitGroup
+		for ch := range chanOfChans.All() {
+			wg.Add(1)
+			chanx.Go("drain "+ch.String(), func() {
+				defer wg.Done()
+				for {
+					val, ok, err := ch.RecvCtx(ctx)
+					if err != nil || !ok {
+						return
+					}
+					if err := out.SendCtx(ctx, val); err != nil {
+						return
+					}
+				}
+			})
+		}
+		wg.Wait()
+	})
+	return out.Receiver()
+}
+
+func worker(id, count int, delay time.Duration, ctx context.Context) chanx.Receiver[int] {
+	name := "worker " + strconv.Itoa(id)
+	out := chanx.Make[int](name, 0)
+	chanx.Go(name, func() {
+		defer out.Close()
+		for n := range count {
+			time.Sleep(delay)
+			fmt.Printf("worker %d: %d\n", id, n)
+			if err := out.SendCtx(ctx, n); err != nil {
+				return
+			}
+		}
+	})
+	return out.Receiver()
+}
@@ -0,0 +1,565 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"channelspractice/internal/lessons"
+	"channelspractice/internal/recorder"
+	"channelspractice/internal/stepper"
+)
+
+const usage = `usage: lessons <command> [flags] [args]
+
+commands:
+  list             list lessons and their titles
+  run <id>         build and run a lesson
+  verify <id|all>  compare lesson output with the documented expected output
+  stress <id|all>  run lessons many times under -race with randomized schedules
+  trace <id>       record channel operations as a Mermaid diagram or SVG timeline
+  step <id>        step through the channel operations of a lesson in the terminal
+  embed            copy the lessons into the playground so it runs them without a toolchain
+  new [id]         scaffold a lesson, the next free number by default
+  migrate          move existing lessons into the cmd/lessonNNN layout
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	switch os.Args[1] {
+	case "list":
+		err = list(os.Args[2:])
+	case "run":
+		err = run(ctx, os.Args[2:])
+	case "verify":
+		err = verify(ctx, os.Args[2:])
+	case "stress":
+		err = stress(ctx, os.Args[2:])
+	case "trace":
+		err = trace(ctx, os.Args[2:])
+	case "step":
+		err = step(ctx, os.Args[2:])
+	case "embed":
+		err = embed(os.Args[2:])
+	case "new":
+		err = newLesson(os.Args[2:])
+	case "migrate":
+		err = migrate(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lessons: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+type common struct {
+	root    string
+	timeout time.Duration
+}
+
+func newFlagSet(name string, c *common) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.StringVar(&c.root, "root", ".", "directory inside the channels module")
+	fs.DurationVar(&c.timeout, "timeout", 10*time.Second, "time a lesson may run before it is interrupted")
+	return fs
+}
+
+func (c *common) discover() (string, []lessons.Lesson, error) {
+	root, err := lessons.ModuleRoot(c.root)
+	if err != nil {
+		return "", nil, err
+	}
+	all, err := lessons.Discover(root)
+	return root, all, err
+}
+
+func list(args []string) error {
+	var c common
+	fs := newFlagSet("list", &c)
+	fs.Parse(args)
+
+	_, all, err := c.discover()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "LESSON\tTITLE\tCODE\tDOC\n")
+	for _, l := range all {
+		dir := l.Dir
+		if dir == "" {
+			dir = "-"
+		}
+		doc := l.Doc
+		if doc == "" {
+			doc = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", l.ID(), l.Title, dir, doc)
+	}
+	return w.Flush()
+}
+
+func run(ctx context.Context, args []string) error {
+	var c common
+	fs := newFlagSet("run", &c)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("run expects exactly one lesson id")
+	}
+
+	root, all, err := c.discover()
+	if err != nil {
+		return err
+	}
+	l, err := lessons.Find(all, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	runner := &lessons.Runner{Root: root, Timeout: c.timeout, Stdout: os.Stdout}
+	defer func() { os.RemoveAll(runner.BinDir) }()
+	res, err := runner.Run(ctx, l)
+	os.Stderr.Write(res.Stderr)
+	if err != nil {
+		return err
+	}
+	if res.TimedOut {
+		fmt.Fprintf(os.Stderr, "lesson %s timed out after %v and was interrupted\n", l.ID(), c.timeout)
+	}
+	if res.ExitCode != 0 {
+		return fmt.Errorf("lesson %s exited with code %d", l.ID(), res.ExitCode)
+	}
+	return nil
+}
+
+func verify(ctx context.Context, args []string) error {
+	var c common
+	fs := newFlagSet("verify", &c)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("verify expects a lesson id or all")
+	}
+
+	root, all, err := c.discover()
+	if err != nil {
+		return err
+	}
+	selected := all
+	if fs.Arg(0) != "all" {
+		l, err := lessons.Find(all, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		selected = []lessons.Lesson{l}
+	}
+
+	runner := &lessons.Runner{Root: root, Timeout: c.timeout, Defaults: true}
+	defer func() { os.RemoveAll(runner.BinDir) }()
+
+	counts := map[lessons.Status]int{}
+	for _, l := range selected {
+		v := verifyOne(ctx, runner, root, l)
+		counts[v.Status]++
+		if v.Reason != "" {
+			fmt.Printf("%s lesson %s: %s\n", v.Status, l.ID(), v.Reason)
+		} else {
+			fmt.Printf("%s lesson %s\n", v.Status, l.ID())
+		}
+		if v.Diff != "" {
+			fmt.Print(v.Diff)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	fmt.Printf("\n%d passed, %d failed, %d skipped\n", counts[lessons.Pass], counts[lessons.Fail], counts[lessons.Skip])
+	if counts[lessons.Fail] > 0 {
+		return fmt.Errorf("%d lesson(s) failed verification", counts[lessons.Fail])
+	}
+	return nil
+}
+
+func verifyOne(ctx context.Context, runner *lessons.Runner, root string, l lessons.Lesson) lessons.Verdict {
+	if !l.HasCode() {
+		return lessons.Verdict{Lesson: l, Status: lessons.Skip, Reason: "no code"}
+	}
+	spec, ok, err := lessons.Spec(root, l)
+	if err != nil {
+		return lessons.Verdict{Lesson: l, Status: lessons.Fail, Reason: err.Error()}
+	}
+	if !ok {
+		return lessons.Verdict{Lesson: l, Status: lessons.Skip, Reason: "no expected output documented"}
+	}
+	res, err := runner.Run(ctx, l)
+	if err != nil {
+		return lessons.Verdict{Lesson: l, Status: lessons.Fail, Reason: err.Error()}
+	}
+	v := lessons.Compare(l, spec, res.Output)
+	if v.Status == lessons.Pass && res.ExitCode != 0 {
+		v.Status = lessons.Fail
+		v.Reason = fmt.Sprintf("exited with code %d", res.ExitCode)
+	}
+	return v
+}
+
+func newLesson(args []string) error {
+	var c common
+	fs := newFlagSet("new", &c)
+	title := fs.String("title", "", "lesson title, taken from an existing doc when empty")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		return fmt.Errorf("new expects at most one lesson id")
+	}
+
+	root, all, err := c.discover()
+	if err != nil {
+		return err
+	}
+	number, suffix := lessons.NextNumber(all), ""
+	if fs.NArg() == 1 {
+		if number, suffix, err = lessons.ParseID(fs.Arg(0)); err != nil {
+			return err
+		}
+	}
+
+	created, err := lessons.New(root, all, number, suffix, *title)
+	for _, path := range created {
+		fmt.Printf("created %s\n", path)
+	}
+	return err
+}
+
+func embed(args []string) error {
+	var c common
+	fs := newFlagSet("embed", &c)
+	dir := fs.String("dir", filepath.FromSlash("internal/playground/programs"), "directory of the generated packages, relative to the module root")
+	fs.Parse(args)
+
+	root, all, err := c.discover()
+	if err != nil {
+		return err
+	}
+	files, err := lessons.Embed(root, all, *dir)
+	if err != nil {
+		return err
+	}
+	// NOTE: start from scratch so removed lessons and files do not linger
+	out := filepath.Join(root, *dir)
+	if err := os.RemoveAll(out); err != nil {
+		return err
+	}
+	for name, data := range files {
+		path := filepath.Join(out, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("wrote %d files to %s\n", len(files), *dir)
+	return nil
+}
+
+func migrate(args []string) error {
+	var c common
+	fs := newFlagSet("migrate", &c)
+	apply := fs.Bool("apply", false, "perform the moves instead of printing them")
+	fs.Parse(args)
+
+	root, all, err := c.discover()
+	if err != nil {
+		return err
+	}
+	moves, err := lessons.MigrationPlan(root, all)
+	if err != nil {
+		return err
+	}
+	if len(moves) == 0 {
+		fmt.Printf("all lessons already use the cmd/lessonNNN layout\n")
+		return nil
+	}
+	for _, m := range moves {
+		fmt.Printf("%s -> %s\n", m.From, m.To)
+	}
+	for _, l := range all {
+		if !l.HasCode() {
+			fmt.Printf("lesson %s has no code, scaffold it with: lessons new %s\n", l.ID(), l.ID())
+		}
+	}
+	if !*apply {
+		fmt.Printf("\nrun again with -apply to move the files\n")
+		return nil
+	}
+	return lessons.Migrate(root, moves)
+}
+
+func stress(ctx context.Context, args []string) error {
+	var c common
+	fs := newFlagSet("stress", &c)
+	runs := fs.Int("n", 1000, "runs per lesson")
+	parallel := fs.Int("parallel", runtime.NumCPU(), "runs executing at the same time")
+	seed := fs.Uint64("seed", uint64(time.Now().UnixNano()), "seed of the first run, run i uses seed+i")
+	procsFlag := fs.String("procs", "1,2,4,8", "GOMAXPROCS values to pick from")
+	yield := fs.Float64("yield", 0.5, "probability of a yield at each chanx operation")
+	race := fs.Bool("race", true, "build with the race detector")
+	record := fs.String("record", "stress-failures.txt", "file the seeds of failing runs are appended to")
+	replay := fs.String("replay", "", "run once with this seed and print the output")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("stress expects a lesson id or all")
+	}
+
+	var procs []int
+	for _, p := range strings.Split(*procsFlag, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid -procs value %q", p)
+		}
+		procs = append(procs, n)
+	}
+
+	root, all, err := c.discover()
+	if err != nil {
+		return err
+	}
+	selected := all
+	if fs.Arg(0) != "all" {
+		l, err := lessons.Find(all, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		selected = []lessons.Lesson{l}
+	}
+
+	// NOTE: the chanx build of a lesson, where there is one, is what the seed perturbs
+	runner := &lessons.Runner{Root: root, Timeout: c.timeout, BuildFlags: []string{"-tags", "chanx"}}
+	if *race {
+		runner.BuildFlags = append(runner.BuildFlags, "-race")
+	}
+	defer func() { os.RemoveAll(runner.BinDir) }()
+
+	if *replay != "" {
+		if len(selected) != 1 {
+			return fmt.Errorf("-replay needs a single lesson")
+		}
+		s, err := strconv.ParseUint(*replay, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid -replay seed, %w", err)
+		}
+		return replayRun(ctx, runner, root, selected[0], lessons.StressConfig{Procs: procs, Yield: *yield}, s)
+	}
+
+	failed := 0
+	for _, l := range selected {
+		if !l.HasCode() {
+			continue
+		}
+		spec, _, err := lessons.Spec(root, l)
+		if err != nil {
+			return err
+		}
+		bin, err := runner.Build(ctx, l)
+		if err != nil {
+			return err
+		}
+
+		cfg := lessons.StressConfig{Runs: *runs, Parallel: *parallel, Seed: *seed, Procs: procs, Yield: *yield, Spec: spec}
+		var (
+			done     int
+			failures []lessons.StressRun
+			outputs  = map[string]int{}
+		)
+		runner.Stress(ctx, bin, cfg, func(run lessons.StressRun) {
+			done++
+			outputs[string(run.Result.Output)]++
+			if run.Failure != "" {
+				failures = append(failures, run)
+			}
+			fmt.Printf("\rlesson %s: %d/%d runs, %d failed", l.ID(), done, *runs, len(failures))
+		})
+		fmt.Printf(", %d distinct outputs\n", len(outputs))
+
+		for i, run := range failures {
+			if i < 3 {
+				reason, _, _ := strings.Cut(run.Failure, "\n")
+				fmt.Printf("  seed %d GOMAXPROCS=%d: %s\n", run.Seed, run.Procs, reason)
+				fmt.Printf("    replay with: go run ./cmd/lessons stress -replay %d %s\n", run.Seed, l.ID())
+			}
+			if err := recordFailure(*record, l, run); err != nil {
+				return err
+			}
+		}
+		if len(failures) > 0 {
+			fmt.Printf("  seeds of %d failing runs appended to %s\n", len(failures), *record)
+		}
+		failed += len(failures)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d run(s) failed", failed)
+	}
+	return nil
+}
+
+func replayRun(ctx context.Context, runner *lessons.Runner, root string, l lessons.Lesson, cfg lessons.StressConfig, seed uint64) error {
+	spec, _, err := lessons.Spec(root, l)
+	if err != nil {
+		return err
+	}
+	cfg.Spec = spec
+	bin, err := runner.Build(ctx, l)
+	if err != nil {
+		return err
+	}
+	run := runner.StressOnce(ctx, bin, seed, cfg)
+	fmt.Printf("lesson %s, seed %d, GOMAXPROCS=%d\n\n", l.ID(), run.Seed, run.Procs)
+	os.Stdout.Write(run.Result.Output)
+	os.Stderr.Write(run.Result.Stderr)
+	if run.Failure != "" {
+		return fmt.Errorf("run failed: %s", run.Failure)
+	}
+	return nil
+}
+
+func recordFailure(path string, l lessons.Lesson, run lessons.StressRun) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to record failing seed, %w", err)
+	}
+	defer f.Close()
+	reason, _, _ := strings.Cut(run.Failure, "\n")
+	_, err = fmt.Fprintf(f, "%s lesson=%s seed=%d gomaxprocs=%d failure=%q\n",
+		time.Now().Format(time.RFC3339), l.ID(), run.Seed, run.Procs, reason)
+	return err
+}
+
+func trace(ctx context.Context, args []string) error {
+	var c common
+	fs := newFlagSet("trace", &c)
+	out := fs.String("o", "", "write the recording to this file, .svg for a timeline, Mermaid otherwise (default stdout)")
+	doc := fs.Bool("doc", false, "embed the Mermaid diagram in the lesson doc under \"## Channel diagram\"")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("trace expects exactly one lesson id")
+	}
+
+	root, all, err := c.discover()
+	if err != nil {
+		return err
+	}
+	l, err := lessons.Find(all, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp("", "lessons-trace-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	name := "trace.md"
+	if strings.EqualFold(filepath.Ext(*out), ".svg") {
+		name = "trace.svg"
+	}
+	recording := filepath.Join(tmp, name)
+
+	runner := &lessons.Runner{Root: root, BinDir: tmp, Timeout: c.timeout, Defaults: true, BuildFlags: []string{"-tags", "chanx"}, Env: []string{recorder.Env + "=" + recording}}
+	res, err := runner.Run(ctx, l)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(recording)
+	if err != nil {
+		return fmt.Errorf("lesson %s did not write a recording, does it have a chanx build calling recorder.FromEnv? %w", l.ID(), err)
+	}
+	if res.ExitCode != 0 {
+		fmt.Fprintf(os.Stderr, "lesson %s exited with code %d\n", l.ID(), res.ExitCode)
+	}
+
+	switch {
+	case *doc:
+		if l.Doc == "" || name != "trace.md" {
+			return fmt.Errorf("-doc needs a lesson doc and a Mermaid recording")
+		}
+		path := filepath.Join(root, l.Doc)
+		current, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		body := "Recorded with `go run ./cmd/lessons trace -doc " + l.ID() + "`, the order changes from run to run.\n\n```mermaid\n" + string(data) + "```"
+		if err := os.WriteFile(path, lessons.SetSection(current, "Channel diagram", body), 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("updated %s\n", l.Doc)
+	case *out != "":
+		if err := os.WriteFile(*out, data, 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("wrote %s\n", *out)
+	default:
+		os.Stdout.Write(data)
+	}
+	return nil
+}
+
+func step(ctx context.Context, args []string) error {
+	var c common
+	fs := newFlagSet("step", &c)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("step expects exactly one lesson id")
+	}
+
+	root, all, err := c.discover()
+	if err != nil {
+		return err
+	}
+	l, err := lessons.Find(all, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	runner := &lessons.Runner{Root: root, BuildFlags: []string{"-tags", "chanx"}}
+	defer func() { os.RemoveAll(runner.BinDir) }()
+	bin, err := runner.Build(ctx, l)
+	if err != nil {
+		return err
+	}
+
+	// NOTE: the lesson owns the terminal, SIGINT from the keyboard reaches it directly
+	signal.Ignore(syscall.SIGINT)
+	cmd := exec.Command(bin)
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), stepper.Env+"=1")
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
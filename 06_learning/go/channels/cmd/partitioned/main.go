@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"channelspractice/internal/partition"
+)
+
+// Jobs are updates to accounts. Updates of one account must be applied in
+// order, so they are partitioned by account instead of shared like the
+// jobs channel of lesson 16.
+type update struct {
+	account string
+	seq     int
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var (
+		mu   sync.Mutex
+		last = map[string]int{}
+	)
+	pool := partition.NewPool(ctx, 3, 4, func(ctx context.Context, worker int, key string, u update) {
+		time.Sleep(time.Duration(rand.IntN(20)) * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		if u.seq != last[key]+1 {
+			fmt.Printf("worker %d: %s update %d out of order, last was %d\n", worker, key, u.seq, last[key])
+		}
+		last[key] = u.seq
+		fmt.Printf("worker %d: %s update %d\n", worker, key, u.seq)
+	})
+
+	accounts := []string{"alice", "bob", "carol", "dave", "erin", "frank"}
+	seq := map[string]int{}
+	send := func(rounds int) {
+		for range rounds {
+			for _, a := range accounts {
+				seq[a]++
+				if err := pool.Submit(ctx, a, update{a, seq[a]}); err != nil {
+					return
+				}
+			}
+		}
+	}
+
+	send(3)
+	fmt.Printf("resizing to 4 workers\n")
+	pool.Resize(4)
+	send(2)
+	pool.Close()
+
+	keys := make([]string, 10000)
+	for i := range keys {
+		keys[i] = "account-" + strconv.Itoa(i)
+	}
+	moved := partition.Moved(partition.NewRing(3, 0), partition.NewRing(4, 0), keys)
+	modulo := 0
+	for _, k := range keys {
+		if partition.Modulo(k, 3) != partition.Modulo(k, 4) {
+			modulo++
+		}
+	}
+	fmt.Printf("3 -> 4 workers moves %.0f%% of keys with consistent hashing, %.0f%% with hash(key) %% n\n",
+		100*float64(moved)/float64(len(keys)), 100*float64(modulo)/float64(len(keys)))
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"channelspractice/internal/pause"
+	"channelspractice/internal/pipeline"
+	"channelspractice/internal/recorder"
+	"channelspractice/internal/stepper"
+)
+
+func main() {
+	check := flag.Bool("check", false, "validate the spec and exit")
+	funcs := flag.Bool("funcs", false, "list the registered stage functions and exit")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: pipeline [-check] <spec.yaml|spec.json>\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	reg := registry()
+	if *funcs {
+		for _, t := range []string{pipeline.Source, pipeline.Map, pipeline.Sink} {
+			fmt.Printf("%s: %s\n", t, strings.Join(reg.Names(t), ", "))
+		}
+		return
+	}
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	spec, err := pipeline.Load(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	p, err := pipeline.Build(spec, reg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if *check {
+		fmt.Printf("pipeline %q is valid\n", spec.Name)
+		return
+	}
+	p.OnSkip = func(stage string, v any, err error) {
+		fmt.Printf("%s: skipped %v: %v\n", stage, v, err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	// NOTE: the stages use chanx channels, so tracing and stepping work like in the lessons
+	defer recorder.FromEnv()()
+	defer stepper.FromEnv(stop)()
+
+	// NOTE: kill -USR1 pauses intake for maintenance, kill -USR2 resumes it
+	p.Pause = pause.New()
+	pause.OnSignals(ctx, p.Pause, func(s pause.State) {
+		fmt.Fprintf(os.Stderr, "pipeline %s, paused for %v in total\n", s, p.Pause.PausedFor().Round(time.Millisecond))
+	})
+
+	err = p.Run(ctx)
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		fmt.Printf("pipeline interrupted\n")
+	case err != nil:
+		fmt.Printf("pipeline error: %v\n", err)
+	default:
+		fmt.Printf("successfully finished processing\n")
+	}
+}
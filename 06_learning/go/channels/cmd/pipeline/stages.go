@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"channelspractice/internal/pipeline"
+)
+
+// registry holds the stage functions of lessons 19 to 22 that the specs in
+// pipelines/ can refer to.
+func registry() *pipeline.Registry {
+	reg := pipeline.NewRegistry()
+	reg.Source("range", rangeSource)
+	reg.Source("spawn", spawnSource)
+	reg.Map("multiply", multiply)
+	reg.Map("reject", reject)
+	reg.Sink("print", printSink)
+	return reg
+}
+
+// rangeSource emits the integers from..to-1, one every interval.
+func rangeSource(args pipeline.Args) (pipeline.SourceFunc, error) {
+	from, err := args.Int("from", 0)
+	if err != nil {
+		return nil, err
+	}
+	to, err := args.Int("to", 10)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := args.Duration("interval", 0)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, emit func(any) error) error {
+		for n := from; n < to; n++ {
+			if err := sleep(ctx, interval); err != nil {
+				return nil
+			}
+			if err := emit(n); err != nil {
+				return nil
+			}
+		}
+		return nil
+	}, nil
+}
+
+// spawnSource emits one channel per worker, each carrying count values, for
+// a bridge stage to flatten like lesson 22.
+func spawnSource(args pipeline.Args) (pipeline.SourceFunc, error) {
+	workers, err := args.Int("workers", 3)
+	if err != nil {
+		return nil, err
+	}
+	count, err := args.Int("count", 5)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := args.Duration("interval", 100*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, emit func(any) error) error {
+		for id := range workers {
+			out := make(chan any)
+			go func() {
+				defer close(out)
+				for n := range count {
+					if err := sleep(ctx, interval); err != nil {
+						return
+					}
+					fmt.Printf("worker %d: %d\n", id, n)
+					select {
+					case out <- n:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+			if err := emit((<-chan any)(out)); err != nil {
+				return nil
+			}
+		}
+		return nil
+	}, nil
+}
+
+// multiply multiplies integers by "by", taking "delay" for each.
+func multiply(args pipeline.Args) (pipeline.MapFunc, error) {
+	by, err := args.Int("by", 2)
+	if err != nil {
+		return nil, err
+	}
+	delay, err := args.Duration("delay", 0)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, v any) (any, error) {
+		n, ok := v.(int)
+		if !ok {
+			return nil, fmt.Errorf("multiply expects an int, got %T", v)
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		return n * by, nil
+	}, nil
+}
+
+// reject fails on the integers listed in "values", like the transform of
+// lesson 19b rejecting 6.
+func reject(args pipeline.Args) (pipeline.MapFunc, error) {
+	values, err := args.Ints("values", nil)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, v any) (any, error) {
+		if n, ok := v.(int); ok && slices.Contains(values, n) {
+			return nil, fmt.Errorf("number %d is invalid", n)
+		}
+		return v, nil
+	}, nil
+}
+
+// printSink prints every value after "prefix".
+func printSink(args pipeline.Args) (pipeline.SinkFunc, error) {
+	prefix, ok := args["prefix"].(string)
+	if _, set := args["prefix"]; set && !ok {
+		return nil, fmt.Errorf("argument \"prefix\" must be a string")
+	}
+	if prefix == "" {
+		prefix = "received"
+	}
+	return func(ctx context.Context, v any) error {
+		fmt.Printf("%s: %v\n", prefix, v)
+		return nil
+	}, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"channelspractice/internal/playground"
+)
+
+func main() {
+	// NOTE: the server runs each lesson in a copy of this binary, which ends here
+	playground.RunChild()
+
+	addr := flag.String("addr", "127.0.0.1:8080", "address to listen on, keep it on localhost")
+	timeout := flag.Duration("timeout", 30*time.Second, "time a lesson may run before it is interrupted")
+	grace := flag.Duration("grace", 3*time.Second, "time a lesson gets to exit after SIGINT before it is killed")
+	flag.Parse()
+
+	if err := serve(*addr, *timeout, *grace); err != nil {
+		fmt.Fprintf(os.Stderr, "playground: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func serve(addr string, timeout, grace time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	handler := playground.New(timeout, grace)
+
+	// NOTE: a base context of our own lets shutdown interrupt the running lessons
+	runs, interruptRuns := context.WithCancel(context.Background())
+	defer interruptRuns()
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return runs },
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.ListenAndServe()
+	}()
+	fmt.Printf("playground listening on http://%s\n", addr)
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	// NOTE: shutdown waits for open streams, interrupt their lesson so they end
+	interruptRuns()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*grace)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("failed to shut down, %w", err)
+	}
+	return nil
+}
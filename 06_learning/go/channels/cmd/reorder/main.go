@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"channelspractice/internal/reorder"
+)
+
+type result struct {
+	seq    int
+	square int
+}
+
+// workers squares jobs on a pool like lesson 16, finishing them in random
+// order. The job numbered lost never comes back.
+func workers(ctx context.Context, jobs, lost int) <-chan result {
+	in := make(chan int)
+	out := make(chan result)
+	go func() {
+		defer close(in)
+		for n := range jobs {
+			select {
+			case <-ctx.Done():
+				return
+			case in <- n:
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range in {
+				time.Sleep(time.Duration(rand.IntN(40)) * time.Millisecond)
+				if n == lost {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case out <- result{n, n * n}:
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+func main() {
+	jobs := flag.Int("jobs", 20, "number of jobs")
+	lost := flag.Int("lost", 5, "job that is never answered, -1 for none")
+	gap := flag.Duration("gap", 50*time.Millisecond, "how long to wait for a missing result")
+	flag.Parse()
+
+	signalCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	runs := []struct {
+		name string
+		opts reorder.Options
+	}{
+		{"skip missing results", reorder.Options{GapTimeout: *gap}},
+		{"fail on missing results", reorder.Options{GapTimeout: *gap, OnGap: reorder.Fail}},
+		{"buffer of 2, skip on overflow", reorder.Options{MaxBuffered: 2}},
+	}
+	for _, r := range runs {
+		fmt.Printf("%s\n", r.name)
+		r.opts.OnSkip = func(from, to int) { fmt.Printf("  skipped %d to %d\n", from, to) }
+		r.opts.OnLate = func(seq int) { fmt.Printf("  dropped late result %d\n", seq) }
+		buf := reorder.New(func(r result) int { return r.seq }, r.opts)
+
+		g, ctx := errgroup.WithContext(signalCtx)
+		ordered := make(chan result)
+		g.Go(func() error {
+			return buf.Run(ctx, workers(ctx, *jobs, *lost), ordered)
+		})
+		g.Go(func() error {
+			for r := range ordered {
+				fmt.Printf("  %d squared is %d\n", r.seq, r.square)
+			}
+			return nil
+		})
+		err := g.Wait()
+		switch {
+		case errors.Is(err, reorder.ErrGap), errors.Is(err, reorder.ErrOverflow):
+			fmt.Printf("  stopped: %v\n", err)
+		case err != nil:
+			fmt.Printf("  error: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"channelspractice/internal/shed"
+)
+
+// Requests arrive faster than lesson 16's three workers can handle them.
+// With the blocking jobs channel the request handler waits for a free slot;
+// with a shedding queue it answers "overloaded" at once and the work that
+// is accepted does not wait long.
+type request struct {
+	id       int
+	priority int
+	arrived  time.Time
+}
+
+func main() {
+	requests := flag.Int("requests", 300, "requests to send")
+	every := flag.Duration("every", 2*time.Millisecond, "time between requests")
+	work := flag.Duration("work", 10*time.Millisecond, "time a worker spends on a request")
+	flag.Parse()
+
+	fmt.Printf("%-14s %9s %9s %10s %8s %9s  %s\n", "policy", "processed", "rejected", "max submit", "intake", "p99 wait", "shed")
+	blocking(*requests, *every, *work)
+	for _, p := range []struct {
+		name   string
+		policy shed.Policy
+	}{
+		{"reject new", shed.RejectNew},
+		{"drop oldest", shed.DropOldest},
+		{"drop lowest", shed.DropLowest},
+	} {
+		shedding(p.name, p.policy, *requests, *every, *work)
+	}
+}
+
+// blocking is lesson 16's buffered channel of 10.
+func blocking(n int, every, work time.Duration) {
+	jobs := make(chan request, 10)
+	waits, wg := workers(func() (request, bool) {
+		r, ok := <-jobs
+		return r, ok
+	}, work)
+
+	var maxSubmit time.Duration
+	intake := time.Now()
+	for id := range n {
+		start := time.Now()
+		jobs <- request{id: id, arrived: start}
+		maxSubmit = max(maxSubmit, time.Since(start))
+		time.Sleep(every)
+	}
+	close(jobs)
+	elapsed := time.Since(intake)
+	wg.Wait()
+	report("blocking chan", len(*waits), 0, maxSubmit, elapsed, *waits, nil)
+}
+
+func shedding(name string, policy shed.Policy, n int, every, work time.Duration) {
+	q := shed.New(shed.Options[request]{
+		Capacity: 10,
+		Policy:   policy,
+		Priority: func(r request) int { return r.priority },
+	})
+	waits, wg := workers(func() (request, bool) {
+		r, err := q.Receive(context.Background())
+		return r, err == nil
+	}, work)
+
+	var (
+		maxSubmit time.Duration
+		rejected  int
+	)
+	intake := time.Now()
+	for id := range n {
+		start := time.Now()
+		err := q.TrySubmit(request{id: id, priority: rand.IntN(3), arrived: start})
+		maxSubmit = max(maxSubmit, time.Since(start))
+		var overloaded *shed.ErrOverloaded
+		if errors.As(err, &overloaded) {
+			// NOTE: a request handler would answer 503 here instead of waiting
+			rejected++
+		}
+		time.Sleep(every)
+	}
+	q.Close()
+	elapsed := time.Since(intake)
+	wg.Wait()
+	s := q.Stats()
+	report(name, len(*waits), rejected, maxSubmit, elapsed, *waits, s.Shed)
+}
+
+func workers(next func() (request, bool), work time.Duration) (*[]time.Duration, *sync.WaitGroup) {
+	var (
+		mu    sync.Mutex
+		waits []time.Duration
+		wg    sync.WaitGroup
+	)
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				r, ok := next()
+				if !ok {
+					return
+				}
+				mu.Lock()
+				waits = append(waits, time.Since(r.arrived))
+				mu.Unlock()
+				time.Sleep(work)
+			}
+		}()
+	}
+	return &waits, &wg
+}
+
+// report prints one row. intake is how long sending every request took,
+// longer than requests*every when the producer was held up.
+func report(name string, processed, rejected int, maxSubmit, intake time.Duration, waits []time.Duration, shedCounts map[shed.Reason]int) {
+	slices.Sort(waits)
+	p99 := waits[int(0.99*float64(len(waits)-1))]
+	fmt.Printf("%-14s %9d %9d %10v %8v %9v  %v\n", name, processed, rejected, maxSubmit.Round(time.Microsecond), intake.Round(10*time.Millisecond), p99.Round(time.Millisecond), shedCounts)
+}
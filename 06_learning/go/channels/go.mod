@@ -3,3 +3,5 @@ module channelspractice
 go 1.24.5
 
 require golang.org/x/sync v0.18.0
+
+require gopkg.in/yaml.v3 v3.0.1
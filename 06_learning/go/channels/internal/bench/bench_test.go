@@ -0,0 +1,116 @@
+package bench
+
+import (
+	"fmt"
+	"testing"
+)
+
+var (
+	buffers  = []int{0, 1, 64}
+	workers  = []int{1, 4, 16}
+	payloads = []int{0, 1024}
+	inputs   = []int{2, 8, 32}
+)
+
+// sweepPayloads runs f as one sub-benchmark per payload size.
+func sweepPayloads(b *testing.B, f func(b *testing.B, payload []byte)) {
+	for _, size := range payloads {
+		p := make([]byte, size)
+		b.Run(fmt.Sprintf("payload=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			f(b, p)
+		})
+	}
+}
+
+func BenchmarkLesson002(b *testing.B) {
+	for _, buf := range buffers {
+		b.Run(fmt.Sprintf("buffer=%d", buf), func(b *testing.B) {
+			sweepPayloads(b, func(b *testing.B, p []byte) { pipe(b, buf, p) })
+		})
+	}
+}
+
+func BenchmarkLesson007(b *testing.B) {
+	for _, w := range workers {
+		b.Run(fmt.Sprintf("workers=%d", w), func(b *testing.B) {
+			for _, buf := range []int{0, 64} {
+				b.Run(fmt.Sprintf("buffer=%d", buf), func(b *testing.B) {
+					sweepPayloads(b, func(b *testing.B, p []byte) { pool(b, w, buf, p) })
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkLesson009(b *testing.B) {
+	for _, w := range workers {
+		b.Run(fmt.Sprintf("workers=%d", w), func(b *testing.B) {
+			sweepPayloads(b, func(b *testing.B, p []byte) { fanOut(b, w, p) })
+		})
+	}
+}
+
+func BenchmarkLesson014(b *testing.B) {
+	// NOTE: limit=0 is a goroutine per item with no semaphore at all
+	for _, limit := range append([]int{0}, workers...) {
+		b.Run(fmt.Sprintf("limit=%d", limit), func(b *testing.B) {
+			sweepPayloads(b, func(b *testing.B, p []byte) { semaphore(b, limit, p) })
+		})
+	}
+}
+
+func BenchmarkLesson016(b *testing.B) {
+	designs := []struct {
+		name    string
+		collect func(b *testing.B, workers int, payload []byte)
+	}{
+		{"channel", collectChan},
+		{"mutex", collectMutex},
+	}
+	for _, d := range designs {
+		b.Run(d.name, func(b *testing.B) {
+			for _, w := range workers {
+				b.Run(fmt.Sprintf("workers=%d", w), func(b *testing.B) {
+					sweepPayloads(b, func(b *testing.B, p []byte) { d.collect(b, w, p) })
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkLesson020(b *testing.B) {
+	for _, withOrDone := range []bool{false, true} {
+		b.Run(fmt.Sprintf("ordone=%t", withOrDone), func(b *testing.B) {
+			for _, n := range inputs {
+				b.Run(fmt.Sprintf("inputs=%d", n), func(b *testing.B) {
+					for _, buf := range []int{0, 64} {
+						b.Run(fmt.Sprintf("buffer=%d", buf), func(b *testing.B) {
+							b.ReportAllocs()
+							merge(b, n, buf, withOrDone)
+						})
+					}
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkLesson022(b *testing.B) {
+	designs := []struct {
+		name    string
+		flatten func(b *testing.B, streams int, payload []byte)
+	}{
+		{"bridge", bridge},
+		{"shared", shared},
+	}
+	for _, d := range designs {
+		b.Run(d.name, func(b *testing.B) {
+			for _, n := range inputs {
+				b.Run(fmt.Sprintf("streams=%d", n), func(b *testing.B) {
+					sweepPayloads(b, func(b *testing.B, p []byte) { d.flatten(b, n, p) })
+				})
+			}
+		})
+	}
+}
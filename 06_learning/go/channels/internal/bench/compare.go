@@ -0,0 +1,375 @@
+package bench
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Results are the benchmark lines of one run, in the format of go test
+// -bench.
+type Results struct {
+	// Config are the "key: value" lines before the benchmarks, goos,
+	// goarch and the like, and the GOMAXPROCS of the benchmark names.
+	Config []string
+	// Names are the benchmarks in the order they first appear.
+	Names []string
+	// Units are the units seen, ns/op first.
+	Units []string
+	// Values holds every measurement, by name and unit.
+	Values map[string]map[string][]float64
+}
+
+// Parse reads the benchmark lines of r and ignores everything else.
+func Parse(r io.Reader) (*Results, error) {
+	res := &Results{Values: map[string]map[string][]float64{}}
+	sc := bufio.NewScanner(r)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if k, v, ok := strings.Cut(text, ": "); ok && !strings.Contains(k, " ") && len(res.Names) == 0 {
+			res.Config = append(res.Config, k+": "+strings.TrimSpace(v))
+			continue
+		}
+		if !strings.HasPrefix(text, "Benchmark") {
+			continue
+		}
+		fields := strings.Fields(text)
+		// NOTE: name, iterations, then value unit pairs
+		if len(fields) < 4 || len(fields)%2 != 0 {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+		// NOTE: the -N suffix is GOMAXPROCS, kept as config so runs with different values still line up
+		name, procs := splitProcs(strings.TrimPrefix(fields[0], "Benchmark"))
+		if c := "gomaxprocs: " + procs; !slices.Contains(res.Config, c) {
+			res.Config = append(res.Config, c)
+		}
+		byUnit, ok := res.Values[name]
+		if !ok {
+			byUnit = map[string][]float64{}
+			res.Values[name] = byUnit
+			res.Names = append(res.Names, name)
+		}
+		for i := 2; i < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse line %d, %w", line, err)
+			}
+			unit := fields[i+1]
+			byUnit[unit] = append(byUnit[unit], v)
+			if !slices.Contains(res.Units, unit) {
+				res.Units = append(res.Units, unit)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read results, %w", err)
+	}
+	return res, nil
+}
+
+// splitProcs splits Name-8 into Name and 8. A name without the suffix
+// ran with GOMAXPROCS 1.
+func splitProcs(name string) (string, string) {
+	i := strings.LastIndexByte(name, '-')
+	if i < 0 {
+		return name, "1"
+	}
+	if _, err := strconv.Atoi(name[i+1:]); err != nil {
+		return name, "1"
+	}
+	return name[:i], name[i+1:]
+}
+
+// Compare writes a table per unit comparing the benchmarks present in
+// both runs, like benchstat: the mean of each without outliers with its
+// spread, the change of the mean, and the p-value of a Mann-Whitney U
+// test. A change whose p-value is not below alpha is shown as ~.
+func Compare(w io.Writer, before, after *Results, alpha float64) {
+	for _, c := range before.Config {
+		if slices.Contains(after.Config, c) {
+			fmt.Fprintln(w, c)
+		}
+	}
+
+	var missing int
+	var names []string
+	for _, name := range before.Names {
+		if _, ok := after.Values[name]; ok {
+			names = append(names, name)
+		} else {
+			missing++
+		}
+	}
+	for _, name := range after.Names {
+		if _, ok := before.Values[name]; !ok {
+			missing++
+		}
+	}
+
+	for _, unit := range before.Units {
+		if !slices.Contains(after.Units, unit) {
+			continue
+		}
+		label, format := metric(unit)
+		rows := [][]string{{"name", "old " + label, "new " + label, "delta", ""}}
+		var logOld, logNew float64
+		var geo int
+		for _, name := range names {
+			x, y := before.Values[name][unit], after.Values[name][unit]
+			if len(x) == 0 || len(y) == 0 {
+				continue
+			}
+			ox, oy := withoutOutliers(x), withoutOutliers(y)
+			mx, my := mean(ox), mean(oy)
+			p := mannWhitneyP(ox, oy)
+			delta, note := "~", fmt.Sprintf("(p=%.3f n=%d+%d)", p, len(ox), len(oy))
+			if slices.Min(ox) == slices.Max(oy) && slices.Max(ox) == slices.Min(oy) {
+				note = "(all equal)"
+			} else if p < alpha && mx != 0 {
+				delta = fmt.Sprintf("%+.2f%%", (my/mx-1)*100)
+			}
+			rows = append(rows, []string{name, format(mx) + spread(ox, mx), format(my) + spread(oy, my), delta, note})
+			if mx > 0 && my > 0 {
+				logOld += math.Log(mx)
+				logNew += math.Log(my)
+				geo++
+			}
+		}
+		if len(rows) == 1 {
+			continue
+		}
+		if geo > 1 {
+			gx, gy := math.Exp(logOld/float64(geo)), math.Exp(logNew/float64(geo))
+			rows = append(rows, []string{"[Geo mean]", format(gx), format(gy), fmt.Sprintf("%+.2f%%", (gy/gx-1)*100), ""})
+		}
+		fmt.Fprintln(w)
+		table(w, rows)
+	}
+	if missing > 0 {
+		fmt.Fprintf(w, "\n%d benchmark(s) only in one of the runs, not compared\n", missing)
+	}
+}
+
+// table writes rows with the name and note columns left aligned and the
+// numbers right aligned.
+func table(w io.Writer, rows [][]string) {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	for _, row := range rows {
+		var b strings.Builder
+		for i, cell := range row {
+			pad := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			switch i {
+			case 0:
+				b.WriteString(cell + pad)
+			case len(row) - 1:
+				b.WriteString("  " + cell)
+			default:
+				b.WriteString("  " + pad + cell)
+			}
+		}
+		fmt.Fprintln(w, strings.TrimRight(b.String(), " "))
+	}
+}
+
+// metric returns the column label of a unit and how to print its values.
+func metric(unit string) (string, func(float64) string) {
+	switch unit {
+	case "ns/op":
+		return "time/op", duration
+	case "B/op":
+		return "alloc/op", size
+	}
+	return unit, func(v float64) string { return sig(v) }
+}
+
+func duration(ns float64) string {
+	switch {
+	case ns >= 1e9:
+		return sig(ns/1e9) + "s"
+	case ns >= 1e6:
+		return sig(ns/1e6) + "ms"
+	case ns >= 1e3:
+		return sig(ns/1e3) + "µs"
+	}
+	return sig(ns) + "ns"
+}
+
+func size(b float64) string {
+	switch {
+	case b >= 1<<30:
+		return sig(b/(1<<30)) + "GB"
+	case b >= 1<<20:
+		return sig(b/(1<<20)) + "MB"
+	case b >= 1<<10:
+		return sig(b/(1<<10)) + "kB"
+	}
+	return sig(b) + "B"
+}
+
+// sig prints v with three significant digits, whole numbers without
+// decimals.
+func sig(v float64) string {
+	switch a := math.Abs(v); {
+	case a >= 100 || a == math.Trunc(a):
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	case a >= 10:
+		return strconv.FormatFloat(v, 'f', 1, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// spread is the largest distance of a value from the mean, relative to
+// the mean.
+func spread(values []float64, m float64) string {
+	if m == 0 {
+		return " ± 0%"
+	}
+	lo, hi := slices.Min(values), slices.Max(values)
+	return fmt.Sprintf(" ± %.0f%%", math.Max(hi-m, m-lo)/m*100)
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// withoutOutliers drops the values more than 1.5 interquartile ranges
+// outside the quartiles.
+func withoutOutliers(values []float64) []float64 {
+	sorted := slices.Sorted(slices.Values(values))
+	q1, q3 := quantile(sorted, 0.25), quantile(sorted, 0.75)
+	lo, hi := q1-1.5*(q3-q1), q3+1.5*(q3-q1)
+	var kept []float64
+	for _, v := range sorted {
+		if v >= lo && v <= hi {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	i := int(pos)
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (pos-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// mannWhitneyP returns the two-sided p-value of the Mann-Whitney U test
+// that x and y come from the same distribution. It is exact for small
+// samples without ties and uses the normal approximation otherwise.
+func mannWhitneyP(x, y []float64) float64 {
+	n1, n2 := len(x), len(y)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+	type obs struct {
+		v     float64
+		fromX bool
+	}
+	all := make([]obs, 0, n1+n2)
+	for _, v := range x {
+		all = append(all, obs{v, true})
+	}
+	for _, v := range y {
+		all = append(all, obs{v, false})
+	}
+	slices.SortFunc(all, func(a, b obs) int {
+		switch {
+		case a.v < b.v:
+			return -1
+		case a.v > b.v:
+			return 1
+		}
+		return 0
+	})
+
+	// NOTE: tied values share the average of their ranks
+	var r1, tieSum float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].fromX {
+				r1 += rank
+			}
+		}
+		t := float64(j - i)
+		tieSum += t*t*t - t
+		i = j
+	}
+	u := r1 - float64(n1*(n1+1))/2
+
+	if tieSum == 0 && n1+n2 <= 50 {
+		dist := uDistribution(n1, n2)
+		var total, below, above float64
+		for k, c := range dist {
+			total += c
+			if float64(k) <= u {
+				below += c
+			}
+			if float64(k) >= u {
+				above += c
+			}
+		}
+		return math.Min(1, 2*math.Min(below, above)/total)
+	}
+
+	n := float64(n1 + n2)
+	mu := float64(n1*n2) / 2
+	sigma := math.Sqrt(float64(n1*n2) / 12 * ((n + 1) - tieSum/(n*(n-1))))
+	if sigma == 0 {
+		return 1
+	}
+	z := math.Max(0, math.Abs(u-mu)-0.5) / sigma
+	return math.Min(1, math.Erfc(z/math.Sqrt2))
+}
+
+// uDistribution returns, for every value of U, how many orderings of n1
+// and n2 distinct values give it.
+func uDistribution(n1, n2 int) []float64 {
+	// NOTE: f[i][j] is the distribution for i and j values, built from f[i-1][j] shifted by j and f[i][j-1]
+	prev := make([][]float64, n2+1)
+	for j := range prev {
+		prev[j] = []float64{1}
+	}
+	for i := 1; i <= n1; i++ {
+		cur := make([][]float64, n2+1)
+		cur[0] = []float64{1}
+		for j := 1; j <= n2; j++ {
+			d := make([]float64, i*j+1)
+			for k, c := range prev[j] {
+				d[k+j] += c
+			}
+			for k, c := range cur[j-1] {
+				d[k] += c
+			}
+			cur[j] = d
+		}
+		prev = cur
+	}
+	return prev[n2]
+}
@@ -0,0 +1,11 @@
+// Package bench measures the concurrency patterns of the lessons against
+// each other: unbuffered against buffered channels, a worker pool against
+// a goroutine per item, channels against a mutex. The benchmarks live in
+// the package tests and sweep buffer size, worker count and payload size
+// as sub-benchmarks; Compare puts two saved runs side by side, like
+// benchstat.
+//
+//	go test -run '^$' -bench . -count 5 ./internal/bench > old.txt
+//	go test -run '^$' -bench . -count 5 ./internal/bench > new.txt
+//	go run ./cmd/bench old.txt new.txt
+package bench
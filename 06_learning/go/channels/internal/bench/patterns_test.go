@@ -0,0 +1,354 @@
+package bench
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// The patterns below are the ones of the lessons with plain channels
+// instead of chanx, so the numbers are the cost of the pattern and not of
+// the instrumentation. Every b.N iteration is one item flowing through the
+// pattern, so ns/op is the cost per item.
+
+// work stands in for what a lesson does with an item. It reads every byte
+// of the payload, so its cost grows with the payload size; a zero payload
+// leaves only the cost of moving items around.
+func work(p []byte) uint64 {
+	h := uint64(14695981039346656037)
+	for _, c := range p {
+		h ^= uint64(c)
+		h *= 1099511628211
+	}
+	return h
+}
+
+// sink keeps the results alive so the compiler cannot drop the work.
+var sink atomic.Uint64
+
+// share returns how many of n items producer i of k sends.
+func share(i, n, k int) int {
+	if i < n%k {
+		return n/k + 1
+	}
+	return n / k
+}
+
+// pipe is lesson 002: one producer, one consumer, one channel of the
+// given buffer size.
+func pipe(b *testing.B, buffer int, payload []byte) {
+	ch := make(chan []byte, buffer)
+	go func() {
+		defer close(ch)
+		for range b.N {
+			ch <- payload
+		}
+	}()
+	var h uint64
+	for p := range ch {
+		h += work(p)
+	}
+	sink.Add(h)
+}
+
+// pool is lesson 007: a fixed number of workers reading jobs and writing
+// results, both channels with the given buffer size.
+func pool(b *testing.B, workers, buffer int, payload []byte) {
+	jobs := make(chan []byte, buffer)
+	results := make(chan uint64, buffer)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				results <- work(p)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for range b.N {
+			jobs <- payload
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	var h uint64
+	for r := range results {
+		h += r
+	}
+	sink.Add(h)
+}
+
+// semaphore is lesson 014: a goroutine per item, at most limit of them
+// running at once. limit 0 drops the semaphore, a goroutine per item with
+// nothing holding it back.
+func semaphore(b *testing.B, limit int, payload []byte) {
+	var wg sync.WaitGroup
+	var sem chan struct{}
+	if limit > 0 {
+		sem = make(chan struct{}, limit)
+	}
+	for range b.N {
+		wg.Add(1)
+		if sem != nil {
+			sem <- struct{}{}
+		}
+		go func() {
+			defer wg.Done()
+			sink.Add(work(payload))
+			if sem != nil {
+				<-sem
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// fanOut is lesson 009: a generator feeding workers that each have their
+// own output channel, merged back into one.
+func fanOut(b *testing.B, workers int, payload []byte) {
+	gen := make(chan []byte)
+	go func() {
+		defer close(gen)
+		for range b.N {
+			gen <- payload
+		}
+	}()
+	results := make(chan uint64)
+	var wg sync.WaitGroup
+	for range workers {
+		out := make(chan uint64)
+		go func() {
+			defer close(out)
+			for p := range gen {
+				out <- work(p)
+			}
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range out {
+				results <- v
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	var h uint64
+	for r := range results {
+		h += r
+	}
+	sink.Add(h)
+}
+
+// collectChan is lesson 016: workers send their results over a channel to
+// the goroutine that collects them.
+func collectChan(b *testing.B, workers int, payload []byte) {
+	jobs := make(chan []byte, workers)
+	results := make(chan uint64, workers)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				results <- work(p)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for range b.N {
+			jobs <- payload
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	collected := make([]uint64, 0, b.N)
+	for r := range results {
+		collected = append(collected, r)
+	}
+	sink.Add(uint64(len(collected)))
+}
+
+// collectMutex is collectChan with the results appended under a mutex
+// instead of sent over a channel.
+func collectMutex(b *testing.B, workers int, payload []byte) {
+	jobs := make(chan []byte, workers)
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		collected = make([]uint64, 0, b.N)
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				v := work(p)
+				mu.Lock()
+				collected = append(collected, v)
+				mu.Unlock()
+			}
+		}()
+	}
+	for range b.N {
+		jobs <- payload
+	}
+	close(jobs)
+	wg.Wait()
+	sink.Add(uint64(len(collected)))
+}
+
+// merge is lesson 020: inputs generators merged into one channel, every
+// send and receive also watching ctx. withOrDone wraps each generator in
+// orDone first, as the lesson does.
+func merge(b *testing.B, inputs, buffer int, withOrDone bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chs := make([]<-chan int, inputs)
+	for i := range inputs {
+		ch := generator(ctx, share(i, b.N, inputs), buffer)
+		if withOrDone {
+			ch = orDone(ctx, ch, buffer)
+		}
+		chs[i] = ch
+	}
+	merged := make(chan int, buffer)
+	var wg sync.WaitGroup
+	for _, ch := range chs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range ch {
+				select {
+				case merged <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+	var n int
+	for v := range merged {
+		n += v
+	}
+	sink.Add(uint64(n))
+}
+
+func generator(ctx context.Context, n, buffer int) <-chan int {
+	out := make(chan int, buffer)
+	go func() {
+		defer close(out)
+		for i := range n {
+			select {
+			case out <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func orDone(ctx context.Context, in <-chan int, buffer int) <-chan int {
+	out := make(chan int, buffer)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// bridge is lesson 022: streams arrive over a channel of channels and are
+// drained into one output.
+func bridge(b *testing.B, streams int, payload []byte) {
+	chanOfChans := make(chan chan []byte)
+	go func() {
+		defer close(chanOfChans)
+		for i := range streams {
+			ch := make(chan []byte)
+			go produce(ch, share(i, b.N, streams), payload)
+			chanOfChans <- ch
+		}
+	}()
+	out := make(chan []byte)
+	go func() {
+		defer close(out)
+		var wg sync.WaitGroup
+		for ch := range chanOfChans {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for p := range ch {
+					out <- p
+				}
+			}()
+		}
+		wg.Wait()
+	}()
+	var h uint64
+	for p := range out {
+		h += work(p)
+	}
+	sink.Add(h)
+}
+
+// shared is bridge without the channel of channels: every producer sends
+// straight into the one output.
+func shared(b *testing.B, streams int, payload []byte) {
+	out := make(chan []byte)
+	var wg sync.WaitGroup
+	for i := range streams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range share(i, b.N, streams) {
+				out <- payload
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	var h uint64
+	for p := range out {
+		h += work(p)
+	}
+	sink.Add(h)
+}
+
+func produce(ch chan<- []byte, n int, payload []byte) {
+	defer close(ch)
+	for range n {
+		ch <- payload
+	}
+}
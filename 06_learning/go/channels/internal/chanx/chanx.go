@@ -0,0 +1,178 @@
+// Package chanx wraps channels so tooling can observe and perturb every
+// channel operation of a lesson without changing what the lesson does.
+//
+// A Chan behaves like the channel it wraps. Send, Recv and Close report to
+// the installed Hook before and after the operation. Inside a select
+// statement use C() for the case and report the outcome with Received or
+// Sent, so the hook still sees the operation.
+//
+// Lessons stay on plain channels. A lesson that can be instrumented keeps a
+// chanx copy of its main.go in main_chanx.go behind the chanx build tag,
+// which the lessons tool builds with -tags chanx.
+package chanx
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Op is a kind of channel operation.
+type Op int
+
+const (
+	Send Op = iota
+	Recv
+	Close
+)
+
+func (o Op) String() string {
+	switch o {
+	case Send:
+		return "send"
+	case Recv:
+		return "recv"
+	default:
+		return "close"
+	}
+}
+
+// Event describes one channel operation.
+type Event struct {
+	Op   Op
+	Chan string
+	// Goroutine is the label of the goroutine performing the operation.
+	Goroutine string
+	// Value is the value sent or received. It is nil before a receive.
+	Value any
+	// OK is false for a receive from a closed channel.
+	OK       bool
+	Len, Cap int
+}
+
+// Hook observes channel operations. Before runs right before the
+// operation may block, After right after it completed.
+type Hook interface {
+	Before(e Event)
+	After(e Event)
+}
+
+var hook atomic.Pointer[Hook]
+
+// SetHook installs h for every Chan. A nil h removes the current hook.
+func SetHook(h Hook) {
+	if h == nil {
+		hook.Store(nil)
+		return
+	}
+	hook.Store(&h)
+}
+
+func current() Hook {
+	if h := hook.Load(); h != nil {
+		return *h
+	}
+	return nil
+}
+
+// Chan is an instrumented channel. A nil *Chan behaves like a nil channel
+// in select statements, so the "set to nil to disable a case" idiom keeps
+// working.
+type Chan[T any] struct {
+	name string
+	ch   chan T
+}
+
+// Make creates an instrumented channel with the given name and buffer size.
+func Make[T any](name string, size int) *Chan[T] {
+	return &Chan[T]{name: name, ch: make(chan T, size)}
+}
+
+// Name returns the name the channel was created with.
+func (c *Chan[T]) Name() string {
+	return c.name
+}
+
+// C returns the underlying channel for use in select statements.
+func (c *Chan[T]) C() chan T {
+	if c == nil {
+		return nil
+	}
+	return c.ch
+}
+
+// Len and Cap report the buffered items and buffer size.
+func (c *Chan[T]) Len() int { return len(c.ch) }
+func (c *Chan[T]) Cap() int { return cap(c.ch) }
+
+func (c *Chan[T]) event(op Op, v any, ok bool) Event {
+	return Event{Op: op, Chan: c.name, Goroutine: goroutineName(), Value: v, OK: ok, Len: len(c.ch), Cap: cap(c.ch)}
+}
+
+func (c *Chan[T]) before(op Op, v any) {
+	if h := current(); h != nil {
+		h.Before(c.event(op, v, true))
+	}
+}
+
+func (c *Chan[T]) after(op Op, v any, ok bool) {
+	if h := current(); h != nil {
+		h.After(c.event(op, v, ok))
+	}
+}
+
+// Send sends v, blocking like a plain channel send.
+func (c *Chan[T]) Send(v T) {
+	c.before(Send, v)
+	c.ch <- v
+	c.after(Send, v, true)
+}
+
+// Recv receives a value; ok is false once the channel is closed and drained.
+func (c *Chan[T]) Recv() (T, bool) {
+	c.before(Recv, nil)
+	v, ok := <-c.ch
+	c.after(Recv, v, ok)
+	return v, ok
+}
+
+// SendCtx sends v unless ctx is done first.
+func (c *Chan[T]) SendCtx(ctx context.Context, v T) error {
+	c.before(Send, v)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case c.ch <- v:
+		c.after(Send, v, true)
+		return nil
+	}
+}
+
+// RecvCtx receives a value unless ctx is done first.
+func (c *Chan[T]) RecvCtx(ctx context.Context) (T, bool, error) {
+	c.before(Recv, nil)
+	select {
+	case <-ctx.Done():
+		var zero T
+		return zero, false, ctx.Err()
+	case v, ok := <-c.ch:
+		c.after(Recv, v, ok)
+		return v, ok, nil
+	}
+}
+
+// Close closes the channel.
+func (c *Chan[T]) Close() {
+	c.before(Close, nil)
+	close(c.ch)
+	c.after(Close, nil, true)
+}
+
+// Received reports a receive that happened through C() in a select.
+func (c *Chan[T]) Received(v T, ok bool) {
+	c.after(Recv, v, ok)
+}
+
+// Sent reports a send that happened through C() in a select.
+func (c *Chan[T]) Sent(v T) {
+	c.after(Send, v, true)
+}
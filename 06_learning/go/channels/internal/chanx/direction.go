@@ -0,0 +1,57 @@
+package chanx
+
+import (
+	"context"
+	"iter"
+)
+
+// Sender is the send-only view of a Chan, the chanx form of chan<- T.
+type Sender[T any] struct {
+	c *Chan[T]
+}
+
+// Receiver is the receive-only view of a Chan, the chanx form of <-chan T.
+type Receiver[T any] struct {
+	c *Chan[T]
+}
+
+// Sender returns the send-only view of c.
+func (c *Chan[T]) Sender() Sender[T] { return Sender[T]{c} }
+
+// Receiver returns the receive-only view of c.
+func (c *Chan[T]) Receiver() Receiver[T] { return Receiver[T]{c} }
+
+// String returns the channel name, so channels of channels print readably.
+func (c *Chan[T]) String() string { return c.name }
+
+// All ranges over received values until the channel is closed, like
+// for v := range ch.
+func (c *Chan[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			v, ok := c.Recv()
+			if !ok || !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func (s Sender[T]) Send(v T)                               { s.c.Send(v) }
+func (s Sender[T]) SendCtx(ctx context.Context, v T) error { return s.c.SendCtx(ctx, v) }
+func (s Sender[T]) Close()                                 { s.c.Close() }
+func (s Sender[T]) Sent(v T)                               { s.c.Sent(v) }
+func (s Sender[T]) String() string                         { return s.c.String() }
+
+// C returns the underlying channel as chan<- T for select statements.
+func (s Sender[T]) C() chan<- T { return s.c.C() }
+
+func (r Receiver[T]) Recv() (T, bool)                              { return r.c.Recv() }
+func (r Receiver[T]) RecvCtx(ctx context.Context) (T, bool, error) { return r.c.RecvCtx(ctx) }
+func (r Receiver[T]) All() iter.Seq[T]                             { return r.c.All() }
+func (r Receiver[T]) Received(v T, ok bool)                        { r.c.Received(v, ok) }
+func (r Receiver[T]) String() string                               { return r.c.String() }
+
+// C returns the underlying channel as <-chan T for select statements. The
+// zero Receiver returns a nil channel.
+func (r Receiver[T]) C() <-chan T { return r.c.C() }
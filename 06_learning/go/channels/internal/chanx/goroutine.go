@@ -0,0 +1,70 @@
+package chanx
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// names maps goroutine ids to the labels given with Go and Label.
+var names sync.Map
+
+// GoroutineHook is implemented by hooks that also want to know when
+// named goroutines start and finish.
+type GoroutineHook interface {
+	Started(name string)
+	Exited(name string)
+}
+
+// Go starts fn in a new goroutine labelled name, so hooks can tell who
+// performed a channel operation.
+func Go(name string, fn func()) {
+	go Named(name, func() error {
+		fn()
+		return nil
+	})()
+}
+
+// Named wraps fn so it runs labelled name, for goroutines started by
+// someone else such as errgroup.Group.Go.
+func Named(name string, fn func() error) func() error {
+	return func() error {
+		id := goid()
+		names.Store(id, name)
+		defer names.Delete(id)
+		if h, ok := current().(GoroutineHook); ok {
+			h.Started(name)
+			defer h.Exited(name)
+		}
+		return fn()
+	}
+}
+
+// Label names the calling goroutine, typically "main".
+func Label(name string) {
+	names.Store(goid(), name)
+}
+
+// goroutineName returns the label of the calling goroutine, or
+// "goroutine N" when it has none.
+func goroutineName() string {
+	id := goid()
+	if name, ok := names.Load(id); ok {
+		return name.(string)
+	}
+	return "goroutine " + strconv.FormatUint(id, 10)
+}
+
+// goid parses the id of the calling goroutine from its stack header,
+// "goroutine 18 [running]:". It is only used while a hook is installed.
+func goid() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
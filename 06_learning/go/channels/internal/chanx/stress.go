@@ -0,0 +1,71 @@
+package chanx
+
+import (
+	"math/rand/v2"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Environment read at startup. When SeedEnv holds a seed, every Chan
+// randomly yields at its operation boundaries, driven by that seed, to
+// shake out interleavings that rarely happen on their own. YieldEnv sets
+// the probability of a yield, 0.5 by default.
+const (
+	SeedEnv  = "CHANX_SEED"
+	YieldEnv = "CHANX_YIELD"
+)
+
+func init() {
+	raw, ok := os.LookupEnv(SeedEnv)
+	if !ok {
+		return
+	}
+	seed, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return
+	}
+	prob := 0.5
+	if p, err := strconv.ParseFloat(os.Getenv(YieldEnv), 64); err == nil {
+		prob = p
+	}
+	SetHook(NewYielder(seed, prob))
+}
+
+// Yielder is a Hook that injects runtime.Gosched calls and short sleeps at
+// channel operation boundaries. The same seed produces the same sequence
+// of decisions, though the Go scheduler still adds its own variation.
+type Yielder struct {
+	mu   sync.Mutex
+	rng  *rand.Rand
+	prob float64
+}
+
+// NewYielder returns a Yielder that perturbs an operation boundary with
+// probability prob.
+func NewYielder(seed uint64, prob float64) *Yielder {
+	return &Yielder{rng: rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)), prob: prob}
+}
+
+func (y *Yielder) Before(Event) { y.perturb() }
+func (y *Yielder) After(Event)  { y.perturb() }
+
+func (y *Yielder) perturb() {
+	y.mu.Lock()
+	roll := y.rng.Float64()
+	spins := y.rng.IntN(4) + 1
+	sleep := time.Duration(y.rng.IntN(200)) * time.Microsecond
+	y.mu.Unlock()
+
+	switch {
+	case roll >= y.prob:
+	case roll < y.prob/4:
+		time.Sleep(sleep)
+	default:
+		for range spins {
+			runtime.Gosched()
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Package checkpoint records how far a pipeline got through each of its
+// sources, so an interrupted run can resume where the last one stopped
+// instead of processing every item again.
+//
+// A stage commits the offset of the next item it has not finished yet,
+// i.e. the number of items of that source it has fully processed. Items in
+// flight when the run stops are not committed and are processed again,
+// which makes the pipeline at-least-once.
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// File is a checkpoint stored in a local file. It is safe for concurrent
+// use.
+type File struct {
+	path string
+
+	mu    sync.Mutex
+	state state
+}
+
+type state struct {
+	Offsets map[string]int `json:"offsets"`
+	Updated time.Time      `json:"updated"`
+}
+
+// Open loads the checkpoint at path. A missing file is an empty
+// checkpoint, every source starts at offset 0.
+func Open(path string) (*File, error) {
+	f := &File{path: path, state: state{Offsets: map[string]int{}}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint, %w", err)
+	}
+	if err := json.Unmarshal(data, &f.state); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s, %w", path, err)
+	}
+	if f.state.Offsets == nil {
+		f.state.Offsets = map[string]int{}
+	}
+	return f, nil
+}
+
+// Offset returns the committed offset of source: the items before it were
+// processed by an earlier run and can be skipped.
+func (f *File) Offset(source string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.state.Offsets[source]
+}
+
+// Commit records that every item of source before offset is done and
+// writes the checkpoint. Offsets only move forward, a lower offset is
+// ignored.
+func (f *File) Commit(source string, offset int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if offset <= f.state.Offsets[source] {
+		return nil
+	}
+	f.state.Offsets[source] = offset
+	f.state.Updated = time.Now()
+	return f.write()
+}
+
+// Reset removes the checkpoint, typically once a run finished every
+// source, so the next run starts from the beginning.
+func (f *File) Reset() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.state = state{Offsets: map[string]int{}}
+	if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove checkpoint, %w", err)
+	}
+	return nil
+}
+
+// write replaces the file atomically: the new content goes to a temporary
+// file in the same directory, is fsynced and renamed over the old one, so
+// a crash leaves either the previous checkpoint or the new one, never a
+// partial write. f.mu must be held.
+func (f *File) write() error {
+	data, err := json.MarshalIndent(f.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint, %w", err)
+	}
+	dir := filepath.Dir(f.path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint, %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(append(data, '\n'))
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint, %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to replace checkpoint, %w", err)
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to sync checkpoint dir, %w", err)
+	}
+	defer d.Close()
+	return d.Sync()
+}
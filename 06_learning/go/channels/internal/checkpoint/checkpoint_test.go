@@ -0,0 +1,74 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func open(t *testing.T, path string) *File {
+	t.Helper()
+	f, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func commit(t *testing.T, f *File, source string, offset int) {
+	t.Helper()
+	if err := f.Commit(source, offset); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPartialWriteKeepsTheLastCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.checkpoint")
+	f := open(t, path)
+	commit(t, f, "a", 3)
+	commit(t, f, "b", 5)
+
+	// NOTE: what a crash halfway through the next write leaves behind
+	partial := filepath.Join(dir, "run.checkpoint.1234.tmp")
+	if err := os.WriteFile(partial, []byte(`{"offsets": {"a": 9, "b`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f = open(t, path)
+	if a, b := f.Offset("a"), f.Offset("b"); a != 3 || b != 5 {
+		t.Errorf("resumed at a=%d b=%d, want the committed a=3 b=5", a, b)
+	}
+
+	commit(t, f, "a", 4)
+	tmps, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tmps) != 1 || tmps[0] != partial {
+		t.Errorf("temporary files after a commit = %v, want only the stale %s", tmps, partial)
+	}
+	if a := open(t, path).Offset("a"); a != 4 {
+		t.Errorf("reopened at a=%d, want 4", a)
+	}
+}
+
+func TestOffsetsOnlyMoveForward(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.checkpoint")
+	f := open(t, path)
+	commit(t, f, "a", 7)
+	commit(t, f, "a", 2)
+	if a := open(t, path).Offset("a"); a != 7 {
+		t.Errorf("offset after committing a lower one = %d, want 7", a)
+	}
+
+	if err := f.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("checkpoint file still there after Reset, %v", err)
+	}
+	if a := open(t, path).Offset("a"); a != 0 {
+		t.Errorf("offset after Reset = %d, want 0", a)
+	}
+}
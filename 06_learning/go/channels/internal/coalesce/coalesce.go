@@ -0,0 +1,175 @@
+// Package coalesce merges concurrent calls for the same key into one, like
+// golang.org/x/sync/singleflight, but every caller waits with its own
+// context. A caller that gives up stops waiting without disturbing the
+// others; only when the last one gives up is the shared call cancelled.
+//
+//	g := coalesce.New[string, []byte](coalesce.Options{TTL: time.Second})
+//	body, shared, err := g.Do(ctx, url, func(ctx context.Context) ([]byte, error) {
+//		return fetch(ctx, url)
+//	})
+package coalesce
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Options configure a group. Zero values disable the cache.
+type Options struct {
+	// TTL keeps successful results for this long, so calls that arrive
+	// shortly after the shared call finished reuse its result too.
+	TTL time.Duration
+	// Now replaces the clock, for tests and simulations.
+	Now func() time.Time
+}
+
+// Stats counts how calls were served.
+type Stats struct {
+	// Calls is the number of times fn actually ran.
+	Calls int
+	// Shared is the number of callers that joined a call in flight.
+	Shared int
+	// Cached is the number of callers served from the cache.
+	Cached int
+	// Cancelled is the number of shared calls cancelled because every
+	// waiter gave up.
+	Cancelled int
+}
+
+// Group coalesces calls per key. The zero value is not usable, use New.
+type Group[K comparable, V any] struct {
+	opts Options
+
+	mu    sync.Mutex
+	calls map[K]*call[V]
+	cache map[K]entry[V]
+	stats Stats
+}
+
+type call[V any] struct {
+	// done is closed once val and err are set.
+	done    chan struct{}
+	val     V
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+type entry[V any] struct {
+	val     V
+	expires time.Time
+}
+
+// New returns an empty group.
+func New[K comparable, V any](opts Options) *Group[K, V] {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Group[K, V]{
+		opts:  opts,
+		calls: map[K]*call[V]{},
+		cache: map[K]entry[V]{},
+	}
+}
+
+// Do returns the result of fn for key. If a call for key is in flight it
+// waits for that one instead of calling fn, and shared reports so; a fresh
+// cached result is returned right away with shared set as well.
+//
+// fn runs with a context detached from the caller's cancellation but
+// keeping its values. It is cancelled only when every caller waiting for
+// it has given up; Do then returns the caller's ctx.Err().
+func (g *Group[K, V]) Do(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (V, bool, error) {
+	g.mu.Lock()
+	if e, ok := g.cache[key]; ok {
+		if g.opts.Now().Before(e.expires) {
+			g.stats.Cached++
+			g.mu.Unlock()
+			return e.val, true, nil
+		}
+		delete(g.cache, key)
+	}
+	c, shared := g.calls[key]
+	if shared {
+		g.stats.Shared++
+	} else {
+		c = g.start(ctx, key, fn)
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, shared, c.err
+	case <-ctx.Done():
+		g.leave(key, c)
+		var zero V
+		return zero, shared, ctx.Err()
+	}
+}
+
+// start runs fn for key in its own goroutine. g.mu must be held.
+func (g *Group[K, V]) start(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) *call[V] {
+	callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	c := &call[V]{done: make(chan struct{}), cancel: cancel}
+	g.calls[key] = c
+	g.stats.Calls++
+
+	go func() {
+		defer cancel()
+		val, err := fn(callCtx)
+
+		g.mu.Lock()
+		// NOTE: a call abandoned by every waiter is already gone from the map, and a new one may have taken its key
+		if g.calls[key] == c {
+			delete(g.calls, key)
+			if err == nil && g.opts.TTL > 0 {
+				g.cache[key] = entry[V]{val, g.opts.Now().Add(g.opts.TTL)}
+			}
+		}
+		g.mu.Unlock()
+
+		c.val, c.err = val, err
+		close(c.done)
+	}()
+	return c
+}
+
+// leave removes a waiter that gave up and cancels the call if it was the
+// last one.
+func (g *Group[K, V]) leave(key K, c *call[V]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c.waiters--
+	if c.waiters > 0 {
+		return
+	}
+	select {
+	case <-c.done:
+		// NOTE: finished while the last waiter was leaving, nothing to cancel
+		return
+	default:
+	}
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.stats.Cancelled++
+	c.cancel()
+}
+
+// Forget drops the cached result for key and detaches a call in flight,
+// so the next Do for key runs fn again.
+func (g *Group[K, V]) Forget(key K) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.cache, key)
+	delete(g.calls, key)
+}
+
+// Stats returns the counters so far.
+func (g *Group[K, V]) Stats() Stats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.stats
+}
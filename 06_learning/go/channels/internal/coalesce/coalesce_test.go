@@ -0,0 +1,110 @@
+package coalesce
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConcurrentCallersShareOneResult(t *testing.T) {
+	const callers = 10
+	g := New[string, int](Options{})
+	release := make(chan struct{})
+	var runs atomic.Int32
+	fn := func(ctx context.Context) (int, error) {
+		runs.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	vals := make([]int, callers)
+	shared := make([]bool, callers)
+	errs := make([]error, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vals[i], shared[i], errs[i] = g.Do(context.Background(), "key", fn)
+		}()
+	}
+	// NOTE: hold the call open until every caller has joined it
+	waitFor(t, "every caller to join", func() bool { return g.Stats().Shared == callers-1 })
+	close(release)
+	wg.Wait()
+
+	if n := runs.Load(); n != 1 {
+		t.Errorf("fn ran %d times, want once", n)
+	}
+	var sharers int
+	for i := range callers {
+		if errs[i] != nil || vals[i] != 42 {
+			t.Errorf("caller %d got %d, %v, want 42", i, vals[i], errs[i])
+		}
+		if shared[i] {
+			sharers++
+		}
+	}
+	if sharers != callers-1 {
+		t.Errorf("%d callers reported a shared result, want %d", sharers, callers-1)
+	}
+}
+
+func TestLastWaiterCancelsTheCall(t *testing.T) {
+	g := New[string, int](Options{})
+	cancelled := make(chan struct{})
+	fn := func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return 0, ctx.Err()
+	}
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	second, cancelSecond := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	for _, ctx := range []context.Context{first, second} {
+		go func() {
+			_, _, err := g.Do(ctx, "key", fn)
+			errs <- err
+		}()
+	}
+	waitFor(t, "the second caller to join", func() bool { return g.Stats().Shared == 1 })
+
+	cancelFirst()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("first caller got %v, want context.Canceled", err)
+	}
+	select {
+	case <-cancelled:
+		t.Fatal("the call was cancelled while a waiter was left")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	cancelSecond()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("second caller got %v, want context.Canceled", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the call kept running after every waiter gave up")
+	}
+	if n := g.Stats().Cancelled; n != 1 {
+		t.Errorf("Stats().Cancelled = %d, want 1", n)
+	}
+}
@@ -0,0 +1,236 @@
+// Package config makes the parameters of a lesson tunable without editing
+// it. A lesson declares each parameter with its default, today's
+// hard-coded value, and reads it after Parse:
+//
+//	cfg := config.New("lesson_018")
+//	workers := cfg.Int("workers", 3, "number of workers")
+//	if err := cfg.Parse(); err != nil {
+//		os.Exit(2)
+//	}
+//
+// A value comes from, in order of precedence:
+//
+//  1. the command line, -workers 5
+//  2. the environment, LESSON_WORKERS=5
+//  3. the config file named by -config or LESSON_CONFIG
+//  4. the default
+//
+// The config file is YAML, or JSON when it ends in .json. Top-level keys
+// apply to every lesson that has the parameter, a section named after the
+// lesson applies only to it and wins:
+//
+//	workers: 4
+//	lesson_018:
+//	  jobs: 20
+//	  fail-job: -1
+//
+// -help lists the parameters of the lesson with their defaults and
+// environment variables.
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the environment variable of every parameter.
+const EnvPrefix = "LESSON_"
+
+// FileEnv names the config file when -config is not given.
+const FileEnv = EnvPrefix + "CONFIG"
+
+// Set is the parameters of one lesson.
+type Set struct {
+	name string
+	fs   *flag.FlagSet
+	file string
+	// params are the names declared by the lesson, in order.
+	params []string
+	// checks validate the parameters once they are all set.
+	checks []func() error
+}
+
+// New returns an empty set for the lesson name, which is also the name
+// of its section in the config file.
+func New(name string) *Set {
+	s := &Set{name: name, fs: flag.NewFlagSet(name, flag.ContinueOnError)}
+	s.fs.StringVar(&s.file, "config", "", "config file, YAML or JSON (env "+FileEnv+")")
+	s.fs.Usage = func() { s.usage(s.fs.Output()) }
+	return s
+}
+
+// Int declares an int parameter.
+func (s *Set) Int(name string, value int, usage string) *int {
+	s.params = append(s.params, name)
+	return s.fs.Int(name, value, usage)
+}
+
+// IntMin declares an int parameter that must be at least min, like a
+// worker count the lesson cannot run with less of.
+func (s *Set) IntMin(name string, value, min int, usage string) *int {
+	p := s.Int(name, value, fmt.Sprintf("%s, at least %d", usage, min))
+	s.checks = append(s.checks, func() error {
+		if *p < min {
+			return fmt.Errorf("invalid value %d for %s, it must be at least %d", *p, name, min)
+		}
+		return nil
+	})
+	return p
+}
+
+// Float declares a float64 parameter.
+func (s *Set) Float(name string, value float64, usage string) *float64 {
+	s.params = append(s.params, name)
+	return s.fs.Float64(name, value, usage)
+}
+
+// Duration declares a time.Duration parameter, written like 100ms.
+func (s *Set) Duration(name string, value time.Duration, usage string) *time.Duration {
+	s.params = append(s.params, name)
+	return s.fs.Duration(name, value, usage)
+}
+
+// String declares a string parameter.
+func (s *Set) String(name string, value, usage string) *string {
+	s.params = append(s.params, name)
+	return s.fs.String(name, value, usage)
+}
+
+// Bool declares a bool parameter.
+func (s *Set) Bool(name string, value bool, usage string) *bool {
+	s.params = append(s.params, name)
+	return s.fs.Bool(name, value, usage)
+}
+
+// Parse reads os.Args, the environment and the config file, see ParseArgs.
+func (s *Set) Parse() error {
+	return s.ParseArgs(os.Args[1:])
+}
+
+// ParseArgs reads args, the environment and the config file. An invalid
+// value is printed with the usage and returned, -help prints the usage and
+// returns flag.ErrHelp; exiting is up to the lesson. Tests pass nil args.
+func (s *Set) ParseArgs(args []string) error {
+	// NOTE: the flag set prints its own errors and the usage
+	if err := s.fs.Parse(args); err != nil {
+		return err
+	}
+	if err := s.apply(); err != nil {
+		fmt.Fprintf(s.fs.Output(), "%v\n", err)
+		s.fs.Usage()
+		return err
+	}
+	return nil
+}
+
+// apply sets the parameters not given as flags from the environment and
+// the config file, then checks them.
+func (s *Set) apply() error {
+	explicit := map[string]bool{}
+	s.fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	file := s.file
+	if !explicit["config"] {
+		file = os.Getenv(FileEnv)
+	}
+	fromFile, err := s.readFile(file)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range s.params {
+		if explicit[name] {
+			continue
+		}
+		env := EnvName(name)
+		if v, ok := os.LookupEnv(env); ok {
+			if err := s.fs.Set(name, v); err != nil {
+				return fmt.Errorf("invalid value %q for %s, %w", v, env, err)
+			}
+			continue
+		}
+		if v, ok := fromFile[name]; ok {
+			if err := s.fs.Set(name, v); err != nil {
+				return fmt.Errorf("invalid value %q for %s in %s, %w", v, name, file, err)
+			}
+		}
+	}
+	for _, check := range s.checks {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readFile returns the values of the config file that apply to this
+// lesson, the lesson's section over the top-level keys.
+func (s *Set) readFile(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config, %w", err)
+	}
+	var doc map[string]any
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &doc)
+	} else {
+		err = yaml.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config %s, %w", path, err)
+	}
+
+	values := map[string]string{}
+	var section map[string]any
+	for k, v := range doc {
+		if m, ok := v.(map[string]any); ok {
+			if k == s.name {
+				section = m
+			}
+			continue
+		}
+		values[k] = fmt.Sprint(v)
+	}
+	for k, v := range section {
+		if !slices.Contains(s.params, k) {
+			return nil, fmt.Errorf("unknown parameter %q in section %s of %s", k, s.name, path)
+		}
+		values[k] = fmt.Sprint(v)
+	}
+	return values, nil
+}
+
+// EnvName returns the environment variable of a parameter, workers ->
+// LESSON_WORKERS, fail-job -> LESSON_FAIL_JOB.
+func EnvName(param string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(param, "-", "_"))
+}
+
+func (s *Set) usage(w io.Writer) {
+	fmt.Fprintf(w, "usage of %s:\n", s.name)
+	if len(s.params) == 0 {
+		fmt.Fprintf(w, "  no tunable parameters\n")
+	}
+	for _, name := range append(slices.Clone(s.params), "config") {
+		f := s.fs.Lookup(name)
+		typ, usage := flag.UnquoteUsage(f)
+		fmt.Fprintf(w, "  -%s %s\n    \t%s", f.Name, typ, usage)
+		if name != "config" {
+			fmt.Fprintf(w, " (env %s, default %s)", EnvName(name), f.DefValue)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "\nflags win over %s* variables, which win over the config file section %q and its top-level keys\n", EnvPrefix, s.name)
+}
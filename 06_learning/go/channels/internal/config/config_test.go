@@ -0,0 +1,69 @@
+package config
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseArgsPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "lessons.yaml")
+	data := "workers: 4\njobs: 7\nretries: 1\nlesson_test:\n  jobs: 9\n"
+	if err := os.WriteFile(file, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(FileEnv, file)
+	t.Setenv(EnvName("retries"), "8")
+
+	s := New("lesson_test")
+	workers := s.Int("workers", 3, "")
+	jobs := s.Int("jobs", 5, "")
+	retries := s.Int("retries", 2, "")
+	delay := s.Int("delay", 1, "")
+	if err := s.ParseArgs([]string{"-workers", "6"}); err != nil {
+		t.Fatal(err)
+	}
+	got := [4]int{*workers, *jobs, *retries, *delay}
+	// NOTE: the flag, the lesson's section, the environment over the file, the default
+	if want := [4]int{6, 9, 8, 1}; got != want {
+		t.Errorf("workers, jobs, retries, delay = %v, want %v", got, want)
+	}
+}
+
+// TestParseArgsReturnsErrors checks that bad input is returned to the
+// caller instead of ending the process.
+func TestParseArgsReturnsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  string
+	}{
+		{name: "unknown flag", args: []string{"-bogus"}},
+		{name: "bad flag value", args: []string{"-workers", "many"}},
+		{name: "bad env value", env: "many"},
+		{name: "help", args: []string{"-help"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv(EnvName("workers"), tt.env)
+			}
+			s := New("lesson_test")
+			s.Int("workers", 3, "")
+			s.fs.SetOutput(io.Discard)
+			if err := s.ParseArgs(tt.args); err == nil {
+				t.Errorf("ParseArgs(%q) = nil, want an error", tt.args)
+			}
+		})
+	}
+}
+
+func TestIntMin(t *testing.T) {
+	s := New("lesson_test")
+	s.IntMin("workers", 3, 1, "number of workers")
+	s.fs.SetOutput(io.Discard)
+	if err := s.ParseArgs([]string{"-workers", "0"}); err == nil {
+		t.Error("ParseArgs accepted 0 workers, want at least 1")
+	}
+}
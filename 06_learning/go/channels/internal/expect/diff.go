@@ -0,0 +1,64 @@
+package expect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// literals returns the spec as plain lines when it only holds literal
+// lines, so a failure can be shown as a diff.
+func (s *Spec) literals() ([]string, bool) {
+	if s == nil {
+		return nil, false
+	}
+	lines := make([]string, 0, len(s.root.Children))
+	for _, n := range s.root.Children {
+		if n.Kind != Literal {
+			return nil, false
+		}
+		lines = append(lines, n.Text)
+	}
+	return lines, true
+}
+
+// Diff returns a line diff of want and got, or "" when they are equal.
+func Diff(want, got []string) string {
+	// lcs[i][j] holds the longest common subsequence of want[i:] and got[j:].
+	lcs := make([][]int, len(want)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(got)+1)
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := len(got) - 1; j >= 0; j-- {
+			if want[i] == got[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var b strings.Builder
+	changed := false
+	i, j := 0, 0
+	for i < len(want) || j < len(got) {
+		switch {
+		case i < len(want) && j < len(got) && want[i] == got[j]:
+			fmt.Fprintf(&b, "  %s\n", want[i])
+			i++
+			j++
+		case i < len(want) && (j == len(got) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&b, "- %s\n", want[i])
+			changed = true
+			i++
+		default:
+			fmt.Fprintf(&b, "+ %s\n", got[j])
+			changed = true
+			j++
+		}
+	}
+	if !changed {
+		return ""
+	}
+	return b.String()
+}
@@ -0,0 +1,301 @@
+package expect
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Result is the outcome of matching output against a spec.
+type Result struct {
+	OK bool
+	// Line is the 1-based output line where matching got stuck, or
+	// len(output)+1 when the output ended too early.
+	Line int
+	// Got is the output line at Line, empty at end of output.
+	Got string
+	// Expected lists the matchers that were acceptable at Line.
+	Expected []*Node
+	// Extra is set when the spec was satisfied but output remained.
+	Extra bool
+
+	output []string
+	spec   *Spec
+}
+
+// Match checks output lines against the spec.
+func (s *Spec) Match(output []string) Result {
+	m := &matcher{output: output, furthest: -1}
+	if m.seq(s.root.Children, 0, m.end) {
+		return Result{OK: true, output: output, spec: s}
+	}
+	res := Result{Line: m.furthest + 1, Expected: m.expected, Extra: m.extra, output: output, spec: s}
+	if m.furthest < len(output) {
+		res.Got = output[m.furthest]
+	}
+	return res
+}
+
+// Report renders a readable explanation of a failed match with the output
+// around the failing line.
+func (r Result) Report() string {
+	if r.OK {
+		return ""
+	}
+	var b strings.Builder
+	switch {
+	case r.Extra:
+		fmt.Fprintf(&b, "unexpected output at line %d: %q\n", r.Line, r.Got)
+	case len(r.output) == 0:
+		fmt.Fprintf(&b, "no output\n")
+	case r.Line > len(r.output):
+		fmt.Fprintf(&b, "output ended at line %d\n", len(r.output))
+	default:
+		fmt.Fprintf(&b, "output line %d did not match: %q\n", r.Line, r.Got)
+	}
+	if len(r.Expected) > 0 {
+		fmt.Fprintf(&b, "expected one of:\n")
+		for _, n := range r.Expected {
+			fmt.Fprintf(&b, "  spec line %d: %s\n", n.Line, n)
+		}
+	}
+
+	const context = 3
+	if len(r.output) > 0 {
+		fmt.Fprintf(&b, "output:\n")
+		from := max(0, r.Line-1-context)
+		to := min(len(r.output), r.Line+context)
+		width := len(strconv.Itoa(to))
+		for i := from; i < to; i++ {
+			marker := " "
+			if i == r.Line-1 {
+				marker = ">"
+			}
+			fmt.Fprintf(&b, "  %s %*d | %s\n", marker, width, i+1, r.output[i])
+		}
+	}
+
+	// NOTE: a spec of plain lines reads best as a diff of the whole output
+	if want, ok := r.spec.literals(); ok {
+		fmt.Fprintf(&b, "diff (- expected, + actual):\n")
+		b.WriteString(Diff(want, r.output))
+	}
+	return b.String()
+}
+
+type matcher struct {
+	output []string
+
+	// furthest is the deepest output position where matching failed,
+	// with the matchers that were tried there.
+	furthest int
+	expected []*Node
+	extra    bool
+}
+
+func (m *matcher) fail(pos int, extra bool, expected ...*Node) {
+	if pos < m.furthest {
+		return
+	}
+	if pos > m.furthest {
+		m.furthest = pos
+		m.expected = nil
+		m.extra = false
+	}
+	m.extra = m.extra || extra
+	for _, n := range expected {
+		if !slices.Contains(m.expected, n) {
+			m.expected = append(m.expected, n)
+		}
+	}
+}
+
+func (m *matcher) end(pos int) bool {
+	if pos == len(m.output) {
+		return true
+	}
+	m.fail(pos, true)
+	return false
+}
+
+// seq matches nodes consecutively from pos and calls k with the position
+// after the last node.
+func (m *matcher) seq(nodes []*Node, pos int, k func(int) bool) bool {
+	if len(nodes) == 0 {
+		return k(pos)
+	}
+	n, rest := nodes[0], nodes[1:]
+	next := func(p int) bool { return m.seq(rest, p, k) }
+
+	switch n.Kind {
+	case Literal, Regex:
+		if pos < len(m.output) && n.matches(m.output[pos]) {
+			return next(pos + 1)
+		}
+		m.fail(pos, false, n)
+		return false
+	case Any:
+		for p := pos; p <= len(m.output); p++ {
+			if next(p) {
+				return true
+			}
+		}
+		return false
+	case Count:
+		line := n.Children[0]
+		p := pos
+		for p < len(m.output) && (n.Max < 0 || p-pos < n.Max) && line.matches(m.output[p]) {
+			p++
+		}
+		if p-pos < n.Min {
+			m.fail(p, false, n)
+			return false
+		}
+		for ; p-pos >= n.Min; p-- {
+			if next(p) {
+				return true
+			}
+		}
+		return false
+	case Ordered:
+		return m.seq(append(slices.Clone(n.Children), rest...), pos, k)
+	default:
+		return m.unordered(n, pos, next)
+	}
+}
+
+// thread is one member of an unordered block: a list of steps that must be
+// matched in order, each step consuming between min and max lines.
+type thread struct {
+	steps []*Node
+}
+
+type threadState struct {
+	step     int
+	consumed int
+}
+
+func minMax(n *Node) (int, int, *Node) {
+	if n.Kind == Count {
+		return n.Min, n.Max, n.Children[0]
+	}
+	return 1, 1, n
+}
+
+// done reports whether the remaining steps of a thread may be skipped.
+func (t thread) done(s threadState) bool {
+	for i := s.step; i < len(t.steps); i++ {
+		lo, _, _ := minMax(t.steps[i])
+		if i == s.step && s.consumed >= lo {
+			continue
+		}
+		if lo > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// pending returns the first step that still needs lines.
+func (t thread) pending(s threadState) *Node {
+	for i := s.step; i < len(t.steps); i++ {
+		lo, _, _ := minMax(t.steps[i])
+		if (i == s.step && s.consumed < lo) || (i > s.step && lo > 0) {
+			return t.steps[i]
+		}
+	}
+	return t.steps[len(t.steps)-1]
+}
+
+// accept returns the states reachable by consuming line, and the steps that
+// were candidates for it.
+func (t thread) accept(s threadState, line string) (next []threadState, heads []*Node) {
+	for i, consumed := s.step, s.consumed; i < len(t.steps); i, consumed = i+1, 0 {
+		lo, hi, matcher := minMax(t.steps[i])
+		if hi < 0 || consumed < hi {
+			heads = append(heads, t.steps[i])
+			if matcher.matches(line) {
+				next = append(next, threadState{step: i, consumed: consumed + 1})
+			}
+		}
+		if consumed < lo {
+			break
+		}
+	}
+	return next, heads
+}
+
+func (m *matcher) unordered(n *Node, start int, k func(int) bool) bool {
+	threads := make([]thread, len(n.Children))
+	for i, child := range n.Children {
+		if child.Kind == Ordered {
+			threads[i] = thread{steps: child.Children}
+		} else {
+			threads[i] = thread{steps: []*Node{child}}
+		}
+	}
+	states := make([]threadState, len(threads))
+	failed := map[string]bool{}
+
+	var walk func(pos int) bool
+	walk = func(pos int) bool {
+		key := stateKey(pos, states)
+		if failed[key] {
+			return false
+		}
+
+		allDone := true
+		for i, t := range threads {
+			if !t.done(states[i]) {
+				allDone = false
+				break
+			}
+		}
+
+		if pos < len(m.output) {
+			var tried []*Node
+			for i, t := range threads {
+				next, heads := t.accept(states[i], m.output[pos])
+				if !t.done(states[i]) || len(next) > 0 {
+					tried = append(tried, heads...)
+				}
+				saved := states[i]
+				for _, s := range next {
+					states[i] = s
+					if walk(pos + 1) {
+						return true
+					}
+				}
+				states[i] = saved
+			}
+			if !allDone {
+				m.fail(pos, false, tried...)
+			}
+		} else if !allDone {
+			var pending []*Node
+			for i, t := range threads {
+				if !t.done(states[i]) {
+					pending = append(pending, t.pending(states[i]))
+				}
+			}
+			m.fail(pos, false, pending...)
+		}
+
+		if allDone && k(pos) {
+			return true
+		}
+		failed[key] = true
+		return false
+	}
+	return walk(start)
+}
+
+func stateKey(pos int, states []threadState) string {
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(pos))
+	for _, s := range states {
+		fmt.Fprintf(&b, ",%d.%d", s.step, s.consumed)
+	}
+	return b.String()
+}
@@ -0,0 +1,144 @@
+package expect
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   string
+		output []string
+		ok     bool
+		// line is the failing output line, checked when ok is false.
+		line int
+	}{
+		{"ordered", "a\nb\nc", []string{"a", "b", "c"}, true, 0},
+		{"ordered out of order", "a\nb\nc", []string{"a", "c", "b"}, false, 2},
+		{"output ended", "a\nb", []string{"a"}, false, 2},
+		{"extra output", "a", []string{"a", "b"}, false, 2},
+		{"comments and blanks", "# header\n\na\n  # indented\nb", []string{"a", "b"}, true, 0},
+		{"regex", `~ request \d+ sent at \d{2}:\d{2}:\d{2}\.\d{3}`, []string{"request 7 sent at 15:04:05.000"}, true, 0},
+		{"regex anchored", `~ worker \d`, []string{"worker 1 done"}, false, 1},
+		{"literal escape", "= ~ not a regex", []string{"~ not a regex"}, true, 0},
+		{"any", "start\n...\nend", []string{"start", "x", "y", "end"}, true, 0},
+		{"any empty", "start\n...\nend", []string{"start", "end"}, true, 0},
+		{"count exact", "count 3 tick\ndone", []string{"tick", "tick", "tick", "done"}, true, 0},
+		{"count too few", "count 3 tick\ndone", []string{"tick", "tick", "done"}, false, 3},
+		{"count too many", "count 2 tick\ndone", []string{"tick", "tick", "tick", "done"}, false, 3},
+		{"count range", "count 1..3 tick\ndone", []string{"tick", "tick", "done"}, true, 0},
+		{"count unbounded", `count 1.. ~ tick \d` + "\ndone", []string{"tick 1", "tick 2", "tick 3", "tick 4", "done"}, true, 0},
+		{"count zero", "count 0..1 tick\ndone", []string{"done"}, true, 0},
+		{"unordered", "unordered {\n  a\n  b\n  c\n}", []string{"c", "a", "b"}, true, 0},
+		{"unordered missing", "unordered {\n  a\n  b\n}\nend", []string{"b", "end"}, false, 2},
+		{"unordered stray", "unordered {\n  a\n  b\n}", []string{"a", "x", "b"}, false, 2},
+		{"unordered count", "unordered {\n  count 2 result\n  done\n}", []string{"result", "done", "result"}, true, 0},
+		{
+			"unordered threads",
+			"unordered {\n  ordered {\n    worker 0: 0\n    worker 0: 1\n  }\n  ordered {\n    worker 1: 0\n    worker 1: 1\n  }\n}\ndone",
+			[]string{"worker 1: 0", "worker 0: 0", "worker 0: 1", "worker 1: 1", "done"},
+			true, 0,
+		},
+		{
+			"unordered thread out of order",
+			"unordered {\n  ordered {\n    worker 0: 0\n    worker 0: 1\n  }\n  other\n}",
+			[]string{"worker 0: 1", "other", "worker 0: 0"},
+			false, 1,
+		},
+		{"ordered group", "ordered {\n  a\n  b\n}\nc", []string{"a", "b", "c"}, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			res := spec.Match(tt.output)
+			if res.OK != tt.ok {
+				t.Fatalf("OK = %v, want %v\n%s", res.OK, tt.ok, res.Report())
+			}
+			if !tt.ok && res.Line != tt.line {
+				t.Errorf("Line = %d, want %d\n%s", res.Line, tt.line, res.Report())
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name, spec, err string
+	}{
+		{"unexpected brace", "a\n}", "line 2: unexpected }"},
+		{"unclosed block", "unordered {\n  a", "line 1: block is never closed"},
+		{"any in unordered", "unordered {\n  ...\n}", "line 2: ... is not allowed"},
+		{"nested unordered", "unordered {\n  unordered {\n    a\n  }\n}", "unordered blocks cannot be nested"},
+		{"any in thread", "unordered {\n  ordered {\n    ...\n  }\n}", "may only hold lines and counts"},
+		{"invalid regex", "~ (", "line 1: invalid regex"},
+		{"count syntax", "count x tick", "count expects"},
+		{"count bounds", "count 3..1 tick", "upper bound 1 is below lower bound 3"},
+		{"count of any", "count 2 ...", "count applies to a single line matcher"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.spec)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Parse error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestFromLines(t *testing.T) {
+	spec := FromLines([]string{"start", "...", "end"})
+	if res := spec.Match([]string{"start", "middle", "end"}); !res.OK {
+		t.Errorf("match failed:\n%s", res.Report())
+	}
+	if res := spec.Match([]string{"start", "middle"}); res.OK {
+		t.Error("matched output without the last line")
+	}
+}
+
+func TestExtract(t *testing.T) {
+	doc := "# Lesson\n\n```go\ncode\n```\n\n```expect\nunordered {\n  a\n}\n```\n\n```expect\nsecond\n```\n"
+	src, ok := Extract([]byte(doc))
+	if !ok {
+		t.Fatal("no expect block found")
+	}
+	if want := "unordered {\n  a\n}"; src != want {
+		t.Errorf("Extract = %q, want %q", src, want)
+	}
+	if _, ok := Extract([]byte("```go\ncode\n```\n")); ok {
+		t.Error("found an expect block in a doc without one")
+	}
+}
+
+func TestReport(t *testing.T) {
+	spec, err := Parse("a\n~ b \\d\nc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := spec.Match([]string{"a", "b x", "c"}).Report()
+	for _, want := range []string{`output line 2 did not match: "b x"`, `spec line 2: ~ b \d`, "> 2 | b x"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report does not contain %q:\n%s", want, report)
+		}
+	}
+
+	// NOTE: a spec of literal lines also gets a diff of the whole output
+	report = FromLines([]string{"a", "b"}).Match([]string{"a", "c"}).Report()
+	if !strings.Contains(report, "diff (- expected, + actual):\n  a\n- b\n+ c\n") {
+		t.Errorf("report does not contain the diff:\n%s", report)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	if d := Diff([]string{"a", "b"}, []string{"a", "b"}); d != "" {
+		t.Errorf("Diff of equal lines = %q, want empty", d)
+	}
+	got := Diff([]string{"a", "b", "c"}, []string{"a", "x", "c", "d"})
+	want := "  a\n- b\n+ x\n  c\n+ d\n"
+	if got != want {
+		t.Errorf("Diff = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,267 @@
+// Package expect matches program output against a small specification
+// language, so lessons whose goroutines print in a nondeterministic order
+// can still be checked.
+//
+// A spec is written one matcher per line, usually in a fenced block with
+// the "expect" info string inside the lesson markdown:
+//
+//	# comments and blank lines are ignored
+//	starting                     literal line
+//	~ request \d sent at .*      regular expression, anchored to the whole line
+//	= ~ not a regex              literal line, for text starting with a keyword
+//	...                          any number of lines
+//	count 3 ~ worker \d ready    exactly 3 matching lines
+//	count 1..5 tick              between 1 and 5 matching lines, "1.." means no upper bound
+//	unordered {                  the lines inside match in any order
+//	  result: 2
+//	  ordered {                  a thread: in order relative to each other,
+//	    worker 0: 0              but interleaved with the rest of the set
+//	    worker 0: 1
+//	  }
+//	}
+//	ordered {                    a plain group outside unordered blocks
+//	}
+//
+// Top-level matchers consume consecutive lines. An unordered block consumes
+// a contiguous run of lines in which every line is claimed by one of its
+// members; "..." is not allowed inside it.
+package expect
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kind identifies a spec node.
+type Kind int
+
+const (
+	Literal Kind = iota
+	Regex
+	Any
+	Count
+	Ordered
+	Unordered
+)
+
+// Node is one element of a parsed spec.
+type Node struct {
+	Kind Kind
+	// Line is the 1-based line of the node in the spec source.
+	Line int
+	// Text is the literal line or the regular expression source.
+	Text string
+	// Min and Max bound a Count node. Max is -1 when unbounded.
+	Min, Max int
+	// Children holds the members of a block, or the single line matcher
+	// of a Count node.
+	Children []*Node
+
+	re *regexp.Regexp
+}
+
+// Spec is a parsed expected-output specification.
+type Spec struct {
+	root *Node
+}
+
+// String renders a node the way it is written in a spec.
+func (n *Node) String() string {
+	switch n.Kind {
+	case Literal:
+		return n.Text
+	case Regex:
+		return "~ " + n.Text
+	case Any:
+		return "..."
+	case Count:
+		bounds := strconv.Itoa(n.Min)
+		switch {
+		case n.Max < 0:
+			bounds += ".."
+		case n.Max != n.Min:
+			bounds += ".." + strconv.Itoa(n.Max)
+		}
+		return "count " + bounds + " " + n.Children[0].String()
+	case Ordered:
+		return "ordered { ... }"
+	default:
+		return "unordered { ... }"
+	}
+}
+
+func (n *Node) matches(line string) bool {
+	switch n.Kind {
+	case Literal:
+		return n.Text == line
+	case Regex:
+		return n.re.MatchString(line)
+	}
+	return false
+}
+
+// Parse parses spec source.
+func Parse(src string) (*Spec, error) {
+	p := &parser{lines: strings.Split(src, "\n")}
+	root, err := p.block(Ordered, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &Spec{root: root}, nil
+}
+
+// FromLines builds a spec of literal lines, treating a line of "..." as
+// any number of lines. It is used for docs that only have a plain
+// expected output block.
+func FromLines(lines []string) *Spec {
+	root := &Node{Kind: Ordered}
+	for i, line := range lines {
+		n := &Node{Kind: Literal, Line: i + 1, Text: line}
+		if strings.TrimSpace(line) == "..." {
+			n.Kind = Any
+		}
+		root.Children = append(root.Children, n)
+	}
+	return &Spec{root: root}
+}
+
+var fencePattern = regexp.MustCompile("^\\s*```+\\s*expect\\s*$")
+
+// Extract returns the source of the first ```expect fenced block of a
+// markdown document.
+func Extract(markdown []byte) (string, bool) {
+	var (
+		src    []string
+		inside bool
+	)
+	for line := range strings.SplitSeq(string(bytes.ReplaceAll(markdown, []byte("\r\n"), []byte("\n"))), "\n") {
+		switch {
+		case !inside && fencePattern.MatchString(line):
+			inside = true
+		case inside && strings.HasPrefix(strings.TrimSpace(line), "```"):
+			return strings.Join(src, "\n"), true
+		case inside:
+			src = append(src, line)
+		}
+	}
+	return "", false
+}
+
+type parser struct {
+	lines []string
+	pos   int
+}
+
+// block parses nodes until the closing brace of a block, or until the end
+// of input for the root block that starts at line 0.
+func (p *parser) block(kind Kind, start int) (*Node, error) {
+	n := &Node{Kind: kind, Line: start}
+	for p.pos < len(p.lines) {
+		lineNo := p.pos + 1
+		raw := strings.TrimRight(p.lines[p.pos], " \t\r")
+		text := strings.TrimSpace(raw)
+		p.pos++
+
+		switch {
+		case text == "" || strings.HasPrefix(text, "#"):
+			continue
+		case text == "}":
+			if start == 0 {
+				return nil, fmt.Errorf("line %d: unexpected }", lineNo)
+			}
+			return n, p.validate(n)
+		case text == "ordered {" || text == "unordered {":
+			kind := Ordered
+			if text == "unordered {" {
+				kind = Unordered
+			}
+			child, err := p.block(kind, lineNo)
+			if err != nil {
+				return nil, err
+			}
+			n.Children = append(n.Children, child)
+		default:
+			child, err := parseMatcher(text, lineNo)
+			if err != nil {
+				return nil, err
+			}
+			n.Children = append(n.Children, child)
+		}
+	}
+	if start != 0 {
+		return nil, fmt.Errorf("line %d: block is never closed", start)
+	}
+	return n, p.validate(n)
+}
+
+// validate enforces the restrictions on unordered blocks: members may be
+// line matchers, counts and ordered threads made of those.
+func (p *parser) validate(n *Node) error {
+	if n.Kind != Unordered {
+		return nil
+	}
+	for _, child := range n.Children {
+		switch child.Kind {
+		case Any:
+			return fmt.Errorf("line %d: ... is not allowed inside an unordered block", child.Line)
+		case Unordered:
+			return fmt.Errorf("line %d: unordered blocks cannot be nested", child.Line)
+		case Ordered:
+			for _, step := range child.Children {
+				if step.Kind != Literal && step.Kind != Regex && step.Kind != Count {
+					return fmt.Errorf("line %d: ordered thread inside an unordered block may only hold lines and counts", step.Line)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+var countPattern = regexp.MustCompile(`^count\s+(\d+)(\.\.(\d*))?\s+(.+)$`)
+
+func parseMatcher(text string, lineNo int) (*Node, error) {
+	switch {
+	case text == "...":
+		return &Node{Kind: Any, Line: lineNo}, nil
+	case strings.HasPrefix(text, "= "):
+		return &Node{Kind: Literal, Line: lineNo, Text: text[2:]}, nil
+	case strings.HasPrefix(text, "~ "):
+		src := strings.TrimSpace(text[2:])
+		re, err := regexp.Compile("^(?:" + src + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid regex, %w", lineNo, err)
+		}
+		return &Node{Kind: Regex, Line: lineNo, Text: src, re: re}, nil
+	case strings.HasPrefix(text, "count "):
+		m := countPattern.FindStringSubmatch(text)
+		if m == nil {
+			return nil, fmt.Errorf("line %d: count expects \"count N[..M] <line>\"", lineNo)
+		}
+		n := &Node{Kind: Count, Line: lineNo}
+		n.Min, _ = strconv.Atoi(m[1])
+		n.Max = n.Min
+		if m[2] != "" {
+			n.Max = -1
+			if m[3] != "" {
+				n.Max, _ = strconv.Atoi(m[3])
+				if n.Max < n.Min {
+					return nil, fmt.Errorf("line %d: count upper bound %d is below lower bound %d", lineNo, n.Max, n.Min)
+				}
+			}
+		}
+		child, err := parseMatcher(m[4], lineNo)
+		if err != nil {
+			return nil, err
+		}
+		if child.Kind != Literal && child.Kind != Regex {
+			return nil, fmt.Errorf("line %d: count applies to a single line matcher", lineNo)
+		}
+		n.Children = []*Node{child}
+		return n, nil
+	default:
+		return &Node{Kind: Literal, Line: lineNo, Text: text}, nil
+	}
+}
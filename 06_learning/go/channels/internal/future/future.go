@@ -0,0 +1,234 @@
+// Package future wraps the one-shot channel of lesson 1 in a typed value.
+// A Future is started with Go, read any number of times with Await and
+// chained with Then; All, Any and Race combine several of them.
+//
+//	price := future.Go(ctx, func(ctx context.Context) (int, error) { return lookup(ctx, "tea") })
+//	total := future.Then(price, func(ctx context.Context, p int) (int, error) { return p * 3, nil })
+//	n, err := total.Await(ctx)
+//
+// Every future owns a context derived from the one it was started with.
+// Cancelling the parent or calling Cancel cancels it, futures chained with
+// Then inherit it, and the combinators cancel the inputs they no longer
+// need. A panic in fn does not crash the program, it settles the future
+// with a *PanicError.
+package future
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"channelspractice/internal/safe"
+)
+
+// Future is the eventual result of a function running in its own
+// goroutine.
+type Future[T any] struct {
+	// parent is the context the future was started with, chained futures
+	// start from it too.
+	parent context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	cancelled bool
+	// chained are the Cancel funcs of futures started with Then.
+	chained []func()
+
+	// done is closed once val and err are set; they never change after.
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// PanicError is the error of a future whose function panicked.
+type PanicError = safe.PanicError
+
+// Go starts fn in a new goroutine and returns its future.
+func Go[T any](ctx context.Context, fn func(ctx context.Context) (T, error)) *Future[T] {
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	f := &Future[T]{parent: parent, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		// NOTE: release the context once settled, the future's result no longer depends on it
+		defer cancel()
+		var val T
+		err := safe.Call(func() (err error) {
+			val, err = fn(ctx)
+			return err
+		})
+		f.val, f.err = val, err
+	}()
+	return f
+}
+
+// Await blocks until the future settles or ctx is done. Giving up on the
+// wait does not cancel the future; use Cancel for that.
+func (f *Future[T]) Await(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Done is closed once the future settles.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Cancel cancels the future's context and those of the futures chained
+// to it with Then. A function that honours it settles with the context
+// error.
+func (f *Future[T]) Cancel() {
+	f.mu.Lock()
+	f.cancelled = true
+	chained := f.chained
+	f.chained = nil
+	f.mu.Unlock()
+
+	f.cancel()
+	for _, cancel := range chained {
+		cancel()
+	}
+}
+
+// result returns the settled value; f.done must be closed.
+func (f *Future[T]) result() (T, error) {
+	return f.val, f.err
+}
+
+// Then returns a future for fn applied to the value of f. It starts from
+// the same context as f, and cancelling f cancels it too. If f fails, fn
+// is not called and the error is passed on.
+func Then[T, U any](f *Future[T], fn func(ctx context.Context, v T) (U, error)) *Future[U] {
+	g := Go(f.parent, func(ctx context.Context) (U, error) {
+		v, err := f.Await(ctx)
+		if err != nil {
+			var zero U
+			return zero, err
+		}
+		return fn(ctx, v)
+	})
+
+	f.mu.Lock()
+	cancelled := f.cancelled
+	if !cancelled {
+		f.chained = append(f.chained, g.Cancel)
+	}
+	f.mu.Unlock()
+	if cancelled {
+		g.Cancel()
+	}
+	return g
+}
+
+// All settles with the values of every future, in order, once all of them
+// succeeded. It fails as soon as one of them fails and cancels the rest.
+func All[T any](ctx context.Context, fs ...*Future[T]) *Future[[]T] {
+	return Go(ctx, func(ctx context.Context) ([]T, error) {
+		defer cancelAll(fs)
+		vals := make([]T, len(fs))
+		for range fs {
+			i, err := first(ctx, fs)
+			if err != nil {
+				return nil, err
+			}
+			v, err := fs[i].result()
+			if err != nil {
+				return nil, fmt.Errorf("future %d: %w", i, err)
+			}
+			vals[i] = v
+			fs = settled(fs, i)
+		}
+		return vals, nil
+	})
+}
+
+// Any settles with the first value of a future that succeeded and cancels
+// the rest. If all of them fail the error joins theirs.
+func Any[T any](ctx context.Context, fs ...*Future[T]) *Future[T] {
+	return Go(ctx, func(ctx context.Context) (T, error) {
+		defer cancelAll(fs)
+		var errs []error
+		for range fs {
+			i, err := first(ctx, fs)
+			if err != nil {
+				var zero T
+				return zero, err
+			}
+			v, err := fs[i].result()
+			if err == nil {
+				return v, nil
+			}
+			errs = append(errs, fmt.Errorf("future %d: %w", i, err))
+			fs = settled(fs, i)
+		}
+		var zero T
+		if len(errs) == 0 {
+			return zero, errors.New("future: no futures")
+		}
+		return zero, errors.Join(errs...)
+	})
+}
+
+// Race settles like the first future to settle, successful or not, and
+// cancels the rest.
+func Race[T any](ctx context.Context, fs ...*Future[T]) *Future[T] {
+	return Go(ctx, func(ctx context.Context) (T, error) {
+		defer cancelAll(fs)
+		i, err := first(ctx, fs)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		return fs[i].result()
+	})
+}
+
+// first waits for the first of fs to settle and returns its index. Futures
+// already handled (nil) are skipped.
+func first[T any](ctx context.Context, fs []*Future[T]) (int, error) {
+	// NOTE: reflect.Select is a select over a number of channels only known at run time
+	cases := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}}
+	index := []int{-1}
+	for i, f := range fs {
+		if f != nil {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(f.done)})
+			index = append(index, i)
+		}
+	}
+	if len(cases) == 1 {
+		return -1, errors.New("future: no futures")
+	}
+	chosen, _, _ := reflect.Select(cases)
+	if chosen == 0 {
+		return -1, ctx.Err()
+	}
+	return index[chosen], nil
+}
+
+// settled returns fs with the future at i marked as handled, keeping the
+// indexes of the others.
+func settled[T any](fs []*Future[T], i int) []*Future[T] {
+	out := make([]*Future[T], len(fs))
+	copy(out, fs)
+	out[i] = nil
+	return out
+}
+
+// cancelAll cancels the futures of fs still running. Settled ones are left
+// alone so futures chained to them carry on.
+func cancelAll[T any](fs []*Future[T]) {
+	for _, f := range fs {
+		select {
+		case <-f.done:
+		default:
+			f.Cancel()
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package future
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// blocked returns a future that runs until its context is cancelled.
+func blocked(ctx context.Context) *Future[int] {
+	return Go(ctx, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+}
+
+func after(ctx context.Context, d time.Duration, v int, err error) *Future[int] {
+	return Go(ctx, func(ctx context.Context) (int, error) {
+		time.Sleep(d)
+		return v, err
+	})
+}
+
+// await fails the test if f has not settled within a second.
+func await[T any](t *testing.T, f *Future[T]) (T, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	v, err := f.Await(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("future did not settle")
+	}
+	return v, err
+}
+
+func TestThen(t *testing.T) {
+	ctx := context.Background()
+	price := after(ctx, 0, 4, nil)
+	total := Then(price, func(ctx context.Context, p int) (int, error) { return p * 3, nil })
+	if v, err := await(t, total); v != 12 || err != nil {
+		t.Errorf("Then = %d, %v, want 12", v, err)
+	}
+
+	errLookup := errors.New("lookup failed")
+	called := false
+	failed := Then(after(ctx, 0, 0, errLookup), func(ctx context.Context, p int) (int, error) {
+		called = true
+		return p, nil
+	})
+	if _, err := await(t, failed); !errors.Is(err, errLookup) || called {
+		t.Errorf("Then of a failed future = %v, fn called %v, want the lookup error and no call", err, called)
+	}
+}
+
+func TestCancelReachesChainedFutures(t *testing.T) {
+	root := blocked(context.Background())
+	chained := Then(root, func(ctx context.Context, v int) (int, error) { return v, nil })
+	// NOTE: the second step waits on its own context, not on root
+	step := Then(chained, func(ctx context.Context, v int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+
+	root.Cancel()
+	for name, f := range map[string]*Future[int]{"root": root, "chained": chained, "step": step} {
+		if _, err := await(t, f); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s settled with %v, want context.Canceled", name, err)
+		}
+	}
+
+	// NOTE: chaining to a cancelled future starts cancelled
+	late := Then(root, func(ctx context.Context, v int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if _, err := await(t, late); !errors.Is(err, context.Canceled) {
+		t.Errorf("future chained after Cancel settled with %v, want context.Canceled", err)
+	}
+}
+
+func TestAll(t *testing.T) {
+	ctx := context.Background()
+	all := All(ctx, after(ctx, 20*time.Millisecond, 1, nil), after(ctx, 0, 2, nil), after(ctx, 10*time.Millisecond, 3, nil))
+	if vals, err := await(t, all); err != nil || !slices.Equal(vals, []int{1, 2, 3}) {
+		t.Errorf("All = %v, %v, want [1 2 3] in input order", vals, err)
+	}
+
+	errBoom := errors.New("boom")
+	slow := blocked(ctx)
+	all = All(ctx, slow, after(ctx, 0, 0, errBoom))
+	if _, err := await(t, all); !errors.Is(err, errBoom) {
+		t.Errorf("All = %v, want the failing future's error", err)
+	}
+	if _, err := await(t, slow); !errors.Is(err, context.Canceled) {
+		t.Errorf("the other future settled with %v, want it cancelled", err)
+	}
+}
+
+func TestAny(t *testing.T) {
+	ctx := context.Background()
+	errBoom := errors.New("boom")
+	slow := blocked(ctx)
+	anyOf := Any(ctx, after(ctx, 0, 0, errBoom), after(ctx, 10*time.Millisecond, 7, nil), slow)
+	if v, err := await(t, anyOf); v != 7 || err != nil {
+		t.Errorf("Any = %d, %v, want the first success 7", v, err)
+	}
+	if _, err := await(t, slow); !errors.Is(err, context.Canceled) {
+		t.Errorf("the slow future settled with %v, want it cancelled", err)
+	}
+
+	errOther := errors.New("other")
+	anyOf = Any(ctx, after(ctx, 0, 0, errBoom), after(ctx, 0, 0, errOther))
+	if _, err := await(t, anyOf); !errors.Is(err, errBoom) || !errors.Is(err, errOther) {
+		t.Errorf("Any of failures = %v, want both errors joined", err)
+	}
+}
+
+func TestPanicSettlesTheFuture(t *testing.T) {
+	f := Go(context.Background(), func(ctx context.Context) (int, error) { panic("boom") })
+	var pe *PanicError
+	if _, err := await(t, f); !errors.As(err, &pe) {
+		t.Errorf("panicking future settled with %v, want a *PanicError", err)
+	}
+}
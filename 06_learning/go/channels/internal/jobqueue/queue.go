@@ -0,0 +1,490 @@
+// Package jobqueue is a durable job queue for the worker pools of the
+// lessons. Jobs live in an append-only log split into segments, so
+// everything enqueued and not yet acked survives a crash or SIGTERM and is
+// delivered again after a restart.
+//
+// The API mirrors a jobs channel:
+//
+//	q, err := jobqueue.Open("jobs", jobqueue.Options{})
+//	q.Enqueue([]byte("resize photo 7"))
+//	job, err := q.Dequeue(ctx) // blocks like <-jobs
+//	... process job.Payload ...
+//	q.Ack(job.ID)              // done, never delivered again
+//	q.Nack(job.ID)             // failed, deliver it again
+//
+// Delivery is at least once: a dequeued job is invisible to other workers
+// until it is acked, nacked or its visibility timeout expires, and the last
+// two put it back in the queue. Job.Attempts counts the deliveries, across
+// restarts too, so workers can give up on a job that keeps failing.
+package jobqueue
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// SyncPolicy decides when writes are flushed to stable storage.
+type SyncPolicy int
+
+const (
+	// SyncAlways fsyncs after every enqueue and ack. Nothing acknowledged
+	// by Enqueue is lost, at the cost of a disk flush per call.
+	SyncAlways SyncPolicy = iota
+	// SyncInterval fsyncs every Options.SyncEvery. A crash loses at most
+	// that window of writes; a lost ack means a job is delivered again.
+	SyncInterval
+	// SyncNever leaves flushing to the operating system. Writes survive a
+	// process crash but not a power loss.
+	SyncNever
+)
+
+// Options tune a queue. The zero value is usable.
+type Options struct {
+	Sync SyncPolicy
+	// SyncEvery is the flush period of SyncInterval, 100ms by default.
+	SyncEvery time.Duration
+	// SegmentSize is the size after which writes move to a new segment,
+	// 4 MiB by default.
+	SegmentSize int64
+	// CompactAfter is the number of acks after which the log is rewritten
+	// with only the unacked jobs, 1024 by default. Negative disables it.
+	CompactAfter int
+	// Visibility is how long a dequeued job stays invisible without an ack
+	// or nack before it is delivered again, 30s by default.
+	Visibility time.Duration
+}
+
+var (
+	// ErrClosed is returned by operations on a closed queue.
+	ErrClosed = errors.New("queue closed")
+	// ErrUnknownJob is returned when acking a job that is not in the queue
+	// or nacking one that is not in flight.
+	ErrUnknownJob = errors.New("unknown job")
+)
+
+// Job is a unit of work taken from the queue.
+type Job struct {
+	ID      uint64
+	Payload []byte
+	// Attempts is 1 on the first delivery and grows with every redelivery.
+	Attempts int
+}
+
+// Queue is a durable FIFO queue. It is safe for concurrent use.
+type Queue struct {
+	dir  string
+	opts Options
+
+	mu         sync.Mutex
+	active     *os.File
+	activeSeq  uint64
+	activeSize int64
+	dirty      bool
+	nextID     uint64
+	jobs       map[uint64][]byte
+	pending    []uint64
+	// inflight maps dequeued jobs to the end of their visibility timeout.
+	inflight map[uint64]time.Time
+	attempts map[uint64]int
+	acks     int
+	// ready is closed and replaced whenever a job becomes available.
+	ready  chan struct{}
+	closed bool
+
+	stopSync func()
+}
+
+// Open opens the queue stored in dir, creating it when needed. Jobs that
+// were enqueued but not acked before the last shutdown or crash are queued
+// again in their original order.
+func Open(dir string, opts Options) (*Queue, error) {
+	if opts.SyncEvery <= 0 {
+		opts.SyncEvery = 100 * time.Millisecond
+	}
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = 4 << 20
+	}
+	if opts.CompactAfter == 0 {
+		opts.CompactAfter = 1024
+	}
+	if opts.Visibility <= 0 {
+		opts.Visibility = 30 * time.Second
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create queue dir, %w", err)
+	}
+	// NOTE: a leftover .tmp is a compaction that crashed before its rename, the old segments are still complete
+	tmps, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	for _, tmp := range tmps {
+		os.Remove(tmp)
+	}
+
+	q := &Queue{
+		dir:      dir,
+		opts:     opts,
+		nextID:   1,
+		jobs:     map[uint64][]byte{},
+		inflight: map[uint64]time.Time{},
+		attempts: map[uint64]int{},
+		ready:    make(chan struct{}),
+	}
+	seqs, err := segments(dir)
+	if err != nil {
+		return nil, err
+	}
+	acked := map[uint64]bool{}
+	for i, seq := range seqs {
+		err := replay(filepath.Join(dir, segmentName(seq)), i == len(seqs)-1, func(r record) {
+			switch r.typ {
+			case recEnqueue:
+				if !acked[r.id] {
+					q.jobs[r.id] = r.payload
+				}
+				q.nextID = max(q.nextID, r.id+1)
+			case recAck:
+				acked[r.id] = true
+				delete(q.jobs, r.id)
+				delete(q.attempts, r.id)
+			case recAttempts:
+				if len(r.payload) == 4 && !acked[r.id] {
+					q.attempts[r.id] = int(binary.BigEndian.Uint32(r.payload))
+				}
+			case recNextID:
+				q.nextID = max(q.nextID, r.id)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		q.activeSeq = seq
+	}
+	for id := range q.jobs {
+		q.pending = append(q.pending, id)
+	}
+	slices.Sort(q.pending)
+
+	// NOTE: recovery always starts a fresh segment and folds the old ones into it when there are any
+	if len(seqs) > 0 {
+		err = q.compact()
+	} else {
+		err = q.roll()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.Sync == SyncInterval {
+		q.stopSync = q.syncLoop()
+	}
+	return q, nil
+}
+
+// Enqueue appends a job and returns its id. With SyncAlways the job is on
+// stable storage when Enqueue returns.
+func (q *Queue) Enqueue(payload []byte) (uint64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return 0, ErrClosed
+	}
+	id := q.nextID
+	if err := q.write(record{typ: recEnqueue, id: id, payload: payload}); err != nil {
+		return 0, err
+	}
+	q.nextID++
+	q.jobs[id] = slices.Clone(payload)
+	q.pending = append(q.pending, id)
+	close(q.ready)
+	q.ready = make(chan struct{})
+	return id, nil
+}
+
+// Dequeue blocks until a job is available, ctx is done or the queue is
+// closed. The job stays in the queue, invisible to other Dequeue calls,
+// until it is acked. It is delivered again when it is nacked, when
+// Options.Visibility passes without an ack, or after the next Open if the
+// process stops first.
+func (q *Queue) Dequeue(ctx context.Context) (Job, error) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return Job{}, ErrClosed
+		}
+		next := q.expire(time.Now())
+		if len(q.pending) > 0 {
+			id := q.pending[0]
+			attempts := q.attempts[id] + 1
+			if err := q.write(record{typ: recAttempts, id: id, payload: binary.BigEndian.AppendUint32(nil, uint32(attempts))}); err != nil {
+				q.mu.Unlock()
+				return Job{}, err
+			}
+			q.pending = q.pending[1:]
+			q.attempts[id] = attempts
+			q.inflight[id] = time.Now().Add(q.opts.Visibility)
+			job := Job{ID: id, Payload: slices.Clone(q.jobs[id]), Attempts: attempts}
+			q.mu.Unlock()
+			return job, nil
+		}
+		ready := q.ready
+		q.mu.Unlock()
+
+		// NOTE: wake up when the earliest in-flight job expires, it becomes available then
+		var expired <-chan time.Time
+		var timer *time.Timer
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			expired = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-ready:
+		case <-expired:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return Job{}, ctx.Err()
+		}
+	}
+}
+
+// expire moves in-flight jobs whose visibility timeout passed back to the
+// queue and returns the earliest remaining deadline. q.mu must be held.
+func (q *Queue) expire(now time.Time) (next time.Time) {
+	var expired []uint64
+	for id, deadline := range q.inflight {
+		switch {
+		case !deadline.After(now):
+			expired = append(expired, id)
+		case next.IsZero() || deadline.Before(next):
+			next = deadline
+		}
+	}
+	slices.Sort(expired)
+	for _, id := range expired {
+		delete(q.inflight, id)
+		q.pending = append(q.pending, id)
+	}
+	return next
+}
+
+// Ack marks a job as done. A job acked after its visibility timeout
+// expired is still removed, even if it was delivered again meanwhile; the
+// later delivery then gets ErrUnknownJob from its own Ack.
+func (q *Queue) Ack(id uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if _, ok := q.jobs[id]; !ok {
+		return fmt.Errorf("failed to ack job %d, %w", id, ErrUnknownJob)
+	}
+	if err := q.write(record{typ: recAck, id: id}); err != nil {
+		return err
+	}
+	delete(q.inflight, id)
+	delete(q.jobs, id)
+	delete(q.attempts, id)
+	q.pending = slices.DeleteFunc(q.pending, func(p uint64) bool { return p == id })
+	q.acks++
+	if q.opts.CompactAfter > 0 && q.acks >= q.opts.CompactAfter {
+		return q.compact()
+	}
+	return nil
+}
+
+// Nack puts an in-flight job back at the end of the queue right away,
+// without waiting for its visibility timeout.
+func (q *Queue) Nack(id uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if _, ok := q.inflight[id]; !ok {
+		return fmt.Errorf("failed to nack job %d, %w", id, ErrUnknownJob)
+	}
+	delete(q.inflight, id)
+	q.pending = append(q.pending, id)
+	close(q.ready)
+	q.ready = make(chan struct{})
+	return nil
+}
+
+// Len returns the number of jobs waiting and the number in flight.
+func (q *Queue) Len() (waiting, inflight int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending), len(q.inflight)
+}
+
+// Compact rewrites the log with only the jobs that are not acked yet.
+func (q *Queue) Compact() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	return q.compact()
+}
+
+// Close flushes the log and wakes up blocked Dequeue calls. Jobs in flight
+// stay in the log and are delivered again after the next Open.
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	close(q.ready)
+	q.mu.Unlock()
+	if q.stopSync != nil {
+		q.stopSync()
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.active.Sync(); err != nil {
+		q.active.Close()
+		return fmt.Errorf("failed to sync queue, %w", err)
+	}
+	return q.active.Close()
+}
+
+// write appends a record to the active segment, rolling over to a new
+// segment when it is full. q.mu must be held.
+func (q *Queue) write(r record) error {
+	if q.activeSize >= q.opts.SegmentSize {
+		if err := q.roll(); err != nil {
+			return err
+		}
+	}
+	buf := r.encode()
+	if _, err := q.active.Write(buf); err != nil {
+		return fmt.Errorf("failed to write queue log, %w", err)
+	}
+	q.activeSize += int64(len(buf))
+	if q.opts.Sync == SyncAlways {
+		if err := q.active.Sync(); err != nil {
+			return fmt.Errorf("failed to sync queue log, %w", err)
+		}
+		return nil
+	}
+	q.dirty = true
+	return nil
+}
+
+// roll seals the active segment and starts the next one. q.mu must be held.
+func (q *Queue) roll() error {
+	if q.active != nil {
+		if err := q.active.Sync(); err != nil {
+			return fmt.Errorf("failed to sync segment, %w", err)
+		}
+		q.active.Close()
+	}
+	q.activeSeq++
+	f, err := os.OpenFile(filepath.Join(q.dir, segmentName(q.activeSeq)), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create segment, %w", err)
+	}
+	q.active, q.activeSize, q.dirty = f, 0, false
+	return syncDir(q.dir)
+}
+
+// compact writes the unacked jobs to a new segment and removes every older
+// segment. The new segment is written under a temporary name and renamed
+// into place, so a crash leaves either the old segments or the compacted
+// one complete, never a mix. q.mu must be held.
+func (q *Queue) compact() error {
+	seq := q.activeSeq + 1
+	final := filepath.Join(q.dir, segmentName(seq))
+	tmp := final + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create compacted segment, %w", err)
+	}
+
+	ids := append(slices.Clone(q.pending), slices.Collect(maps.Keys(q.inflight))...)
+	slices.Sort(ids)
+	buf := record{typ: recNextID, id: q.nextID}.encode()
+	for _, id := range ids {
+		buf = append(buf, record{typ: recEnqueue, id: id, payload: q.jobs[id]}.encode()...)
+		if n := q.attempts[id]; n > 0 {
+			buf = append(buf, record{typ: recAttempts, id: id, payload: binary.BigEndian.AppendUint32(nil, uint32(n))}.encode()...)
+		}
+	}
+	_, err = f.Write(buf)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write compacted segment, %w", err)
+	}
+	if err := os.Rename(tmp, final); err != nil {
+		return fmt.Errorf("failed to install compacted segment, %w", err)
+	}
+
+	old, err := segments(q.dir)
+	if err != nil {
+		return err
+	}
+	if q.active != nil {
+		q.active.Close()
+		q.active = nil
+	}
+	q.activeSeq = seq
+	if err := q.roll(); err != nil {
+		return err
+	}
+	for _, s := range old {
+		if s < seq {
+			if err := os.Remove(filepath.Join(q.dir, segmentName(s))); err != nil {
+				return fmt.Errorf("failed to remove compacted segment, %w", err)
+			}
+		}
+	}
+	q.acks = 0
+	return syncDir(q.dir)
+}
+
+func (q *Queue) syncLoop() func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(q.opts.SyncEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				q.mu.Lock()
+				if q.dirty && !q.closed {
+					q.active.Sync()
+					q.dirty = false
+				}
+				q.mu.Unlock()
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,294 @@
+package jobqueue
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// open opens the queue in dir and fails the test on error.
+func open(t *testing.T, dir string, opts Options) *Queue {
+	t.Helper()
+	q, err := Open(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+// crash abandons q the way a killed process would: no Close, no final
+// sync, only the file handle released.
+func crash(q *Queue) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.active.Close()
+}
+
+func enqueue(t *testing.T, q *Queue, payloads ...string) []uint64 {
+	t.Helper()
+	var ids []uint64
+	for _, p := range payloads {
+		id, err := q.Enqueue([]byte(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func dequeue(t *testing.T, q *Queue) Job {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	job, err := q.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("Dequeue: %v", err)
+	}
+	return job
+}
+
+// drain dequeues and acks every waiting job and returns their payloads.
+func drain(t *testing.T, q *Queue) []string {
+	t.Helper()
+	var got []string
+	for {
+		if waiting, _ := q.Len(); waiting == 0 {
+			return got
+		}
+		job := dequeue(t, q)
+		got = append(got, string(job.Payload))
+		if err := q.Ack(job.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// lastSegment returns the path of the newest segment in dir.
+func lastSegment(t *testing.T, dir string) string {
+	t.Helper()
+	seqs, err := segments(dir)
+	if err != nil || len(seqs) == 0 {
+		t.Fatalf("no segments in %s, %v", dir, err)
+	}
+	return filepath.Join(dir, segmentName(seqs[len(seqs)-1]))
+}
+
+func TestReopenAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+	q := open(t, dir, Options{})
+	ids := enqueue(t, q, "a", "b", "c", "d")
+	if err := q.Ack(ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	// NOTE: b is in flight when the process dies, it must come back
+	if job := dequeue(t, q); job.ID != ids[1] {
+		t.Fatalf("dequeued job %d, want %d", job.ID, ids[1])
+	}
+	crash(q)
+
+	q = open(t, dir, Options{})
+	defer q.Close()
+	if got := drain(t, q); !slices.Equal(got, []string{"b", "c", "d"}) {
+		t.Errorf("after the crash got %q, want the unacked b, c, d in order", got)
+	}
+	if id := enqueue(t, q, "e")[0]; id <= ids[3] {
+		t.Errorf("new job got id %d, reused an id up to %d", id, ids[3])
+	}
+}
+
+func TestTornTailIsTruncated(t *testing.T) {
+	tests := []struct {
+		name string
+		// tear damages the encoded record that was about to be appended.
+		tear func(rec []byte) []byte
+	}{
+		{"short write", func(rec []byte) []byte { return rec[:len(rec)-3] }},
+		{"bad checksum", func(rec []byte) []byte {
+			rec[len(rec)-1] ^= 0xff
+			return rec
+		}},
+		{"partial header", func(rec []byte) []byte { return rec[:headerSize/2] }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			q := open(t, dir, Options{})
+			enqueue(t, q, "a", "b")
+			crash(q)
+
+			path := lastSegment(t, dir)
+			before, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Write(tt.tear(record{typ: recEnqueue, id: 3, payload: []byte("c")}.encode()))
+			f.Close()
+
+			// NOTE: replay directly, Open would fold the segment into a compacted one
+			if err := replay(path, true, func(record) {}); err != nil {
+				t.Fatal(err)
+			}
+			after, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if after.Size() != before.Size() {
+				t.Errorf("segment is %d bytes after replay, want the %d before the torn record", after.Size(), before.Size())
+			}
+
+			q = open(t, dir, Options{})
+			defer q.Close()
+			if got := drain(t, q); !slices.Equal(got, []string{"a", "b"}) {
+				t.Errorf("got %q, want the complete records a, b", got)
+			}
+		})
+	}
+}
+
+func TestTornRecordBeforeTheTailIsAnError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, segmentName(1))
+	data := record{typ: recEnqueue, id: 1, payload: []byte("a")}.encode()
+	data[len(data)-1] ^= 0xff
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, segmentName(2)), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(dir, Options{}); err == nil {
+		t.Error("Open accepted a corrupted segment that is not the last one")
+	}
+}
+
+func TestCompactKeepsLiveJobs(t *testing.T) {
+	dir := t.TempDir()
+	q := open(t, dir, Options{CompactAfter: -1})
+	ids := enqueue(t, q, "a", "b", "c", "d", "e")
+	for _, id := range []uint64{ids[0], ids[2], ids[4]} {
+		if err := q.Ack(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	inflight := dequeue(t, q)
+	if err := q.Compact(); err != nil {
+		t.Fatal(err)
+	}
+
+	seqs, err := segments(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var live []uint64
+	for _, seq := range seqs {
+		f, err := os.Open(filepath.Join(dir, segmentName(seq)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := bufio.NewReader(f)
+		for {
+			rec, _, err := readRecord(r)
+			if err != nil {
+				break
+			}
+			if rec.typ == recAck {
+				t.Errorf("compacted log still holds the ack of job %d", rec.id)
+			}
+			if rec.typ == recEnqueue {
+				live = append(live, rec.id)
+			}
+		}
+		f.Close()
+	}
+	// NOTE: the in-flight job is not acked yet, so it is live too
+	if want := []uint64{ids[1], ids[3]}; !slices.Equal(live, want) {
+		t.Errorf("compacted log holds jobs %v, want %v", live, want)
+	}
+	if inflight.ID != ids[1] {
+		t.Fatalf("dequeued job %d, want %d", inflight.ID, ids[1])
+	}
+	q.Close()
+
+	q = open(t, dir, Options{})
+	defer q.Close()
+	if got := drain(t, q); !slices.Equal(got, []string{"b", "d"}) {
+		t.Errorf("after reopening got %q, want b, d", got)
+	}
+}
+
+// TestNackCountsAttempts drives a job through the retry loop of a worker
+// that drops a job after maxAttempts: nack until then, ack to drop it.
+func TestNackCountsAttempts(t *testing.T) {
+	const maxAttempts = 3
+	dir := t.TempDir()
+	q := open(t, dir, Options{})
+	id := enqueue(t, q, "flaky")[0]
+
+	job := dequeue(t, q)
+	if err := q.Nack(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Nack(job.ID); !errors.Is(err, ErrUnknownJob) {
+		t.Errorf("second Nack of a job no longer in flight = %v, want ErrUnknownJob", err)
+	}
+	q.Close()
+
+	// NOTE: the count survives a restart
+	q = open(t, dir, Options{})
+	defer q.Close()
+	for want := 2; ; want++ {
+		job = dequeue(t, q)
+		if job.ID != id || job.Attempts != want {
+			t.Fatalf("got job %d attempt %d, want job %d attempt %d", job.ID, job.Attempts, id, want)
+		}
+		if job.Attempts >= maxAttempts {
+			break
+		}
+		if err := q.Nack(job.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.Ack(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if waiting, inflight := q.Len(); waiting+inflight != 0 {
+		t.Errorf("Len = %d waiting, %d in flight after dropping the job, want none", waiting, inflight)
+	}
+}
+
+func TestVisibilityTimeoutRedelivers(t *testing.T) {
+	const visibility = 50 * time.Millisecond
+	q := open(t, t.TempDir(), Options{Visibility: visibility})
+	defer q.Close()
+	id := enqueue(t, q, "slow")[0]
+
+	start := time.Now()
+	first := dequeue(t, q)
+	// NOTE: blocks until the first delivery expires, nothing else is queued
+	second := dequeue(t, q)
+	if waited := time.Since(start); waited < visibility {
+		t.Errorf("redelivered after %v, before the visibility timeout of %v", waited, visibility)
+	}
+	if first.ID != id || second.ID != id || second.Attempts != 2 {
+		t.Errorf("got job %d then job %d attempt %d, want job %d twice, attempt 2", first.ID, second.ID, second.Attempts, id)
+	}
+
+	// NOTE: the late ack of the first delivery still removes the job
+	if err := q.Ack(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Ack(second.ID); !errors.Is(err, ErrUnknownJob) {
+		t.Errorf("Ack of the second delivery = %v, want ErrUnknownJob", err)
+	}
+}
@@ -0,0 +1,157 @@
+package jobqueue
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Record types in the log.
+const (
+	recEnqueue byte = 1
+	recAck     byte = 2
+	// recNextID records the next job id in a compacted segment, so ids are
+	// never reused even when every job has been acked.
+	recNextID byte = 3
+	// recAttempts records the delivery count of a job, written on every
+	// dequeue so the count survives a restart.
+	recAttempts byte = 4
+)
+
+// A record on disk is
+//
+//	length  uint32  bytes after the checksum
+//	crc     uint32  CRC-32C of the bytes after it
+//	type    byte
+//	id      uint64
+//	payload []byte
+const headerSize = 8
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type record struct {
+	typ     byte
+	id      uint64
+	payload []byte
+}
+
+func (r record) encode() []byte {
+	body := make([]byte, 9+len(r.payload))
+	body[0] = r.typ
+	binary.BigEndian.PutUint64(body[1:], r.id)
+	copy(body[9:], r.payload)
+
+	buf := make([]byte, headerSize+len(body))
+	binary.BigEndian.PutUint32(buf[0:], uint32(len(body)))
+	binary.BigEndian.PutUint32(buf[4:], crc32.Checksum(body, crcTable))
+	copy(buf[headerSize:], body)
+	return buf
+}
+
+var errTorn = errors.New("torn record")
+
+// readRecord reads the next record. It returns io.EOF at a clean end of
+// the segment and errTorn for a partial or corrupted record.
+func readRecord(r *bufio.Reader) (record, int, error) {
+	var header [headerSize]byte
+	n, err := io.ReadFull(r, header[:])
+	if err == io.EOF {
+		return record{}, 0, io.EOF
+	}
+	if err != nil {
+		return record{}, n, errTorn
+	}
+	size := binary.BigEndian.Uint32(header[0:])
+	sum := binary.BigEndian.Uint32(header[4:])
+	if size < 9 || size > maxRecordSize {
+		return record{}, n, errTorn
+	}
+	body := make([]byte, size)
+	m, err := io.ReadFull(r, body)
+	n += m
+	if err != nil || crc32.Checksum(body, crcTable) != sum {
+		return record{}, n, errTorn
+	}
+	return record{typ: body[0], id: binary.BigEndian.Uint64(body[1:9]), payload: body[9:]}, n, nil
+}
+
+const maxRecordSize = 64 << 20
+
+// segment file names carry a sequence number so they sort in log order.
+const segmentExt = ".wal"
+
+func segmentName(seq uint64) string {
+	return fmt.Sprintf("%020d%s", seq, segmentExt)
+}
+
+// segments lists the segment sequence numbers in dir in log order.
+func segments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue dir, %w", err)
+	}
+	var seqs []uint64
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), segmentExt)
+		if !ok || e.IsDir() {
+			continue
+		}
+		seq, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs, nil
+}
+
+// replay calls apply for every record of a segment. A torn record at the
+// end of the last segment is what a crash during a write leaves behind:
+// the segment is truncated to the last complete record. Anywhere else it
+// is corruption and an error.
+func replay(path string, last bool, apply func(record)) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open segment, %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var offset int64
+	for {
+		rec, n, err := readRecord(r)
+		if err == io.EOF {
+			return nil
+		}
+		if errors.Is(err, errTorn) {
+			if !last {
+				return fmt.Errorf("segment %s is corrupted at offset %d", filepath.Base(path), offset)
+			}
+			if err := f.Truncate(offset); err != nil {
+				return fmt.Errorf("failed to truncate torn segment, %w", err)
+			}
+			return f.Sync()
+		}
+		apply(rec)
+		offset += int64(n)
+	}
+}
+
+// syncDir makes renames and removals in dir durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
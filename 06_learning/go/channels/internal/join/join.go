@@ -0,0 +1,146 @@
+// Package join pairs the values of two channels. Where merge in lesson 20
+// interleaves streams into one, these operators combine a value from each
+// side into a Pair:
+//
+//   - Zip pairs values by position: the first of a with the first of b,
+//     and so on.
+//   - CombineLatest emits the latest of both every time either side sends.
+//   - WithLatestFrom emits for every value of a, sampling the latest of b.
+//
+// Every operator closes its output when ctx is done. Inputs are not
+// drained once the output closes, so a producer still sending must be
+// stopped through ctx as well, like the generators of lesson 20.
+package join
+
+import "context"
+
+// Pair is one value from each side.
+type Pair[A, B any] struct {
+	Left  A
+	Right B
+}
+
+// Zip pairs the n-th value of a with the n-th value of b. It closes once
+// either side closes; a value the other side already sent for a partner
+// that never came is dropped.
+func Zip[A, B any](ctx context.Context, a <-chan A, b <-chan B) <-chan Pair[A, B] {
+	out := make(chan Pair[A, B])
+	go func() {
+		defer close(out)
+		for {
+			var (
+				p          Pair[A, B]
+				gotA, gotB bool
+			)
+			// NOTE: read both sides in whatever order they are ready, a nil channel disables its case
+			ac, bc := a, b
+			for !gotA || !gotB {
+				select {
+				case <-ctx.Done():
+					return
+				case v, ok := <-ac:
+					if !ok {
+						return
+					}
+					p.Left, gotA, ac = v, true, nil
+				case v, ok := <-bc:
+					if !ok {
+						return
+					}
+					p.Right, gotB, bc = v, true, nil
+				}
+			}
+			if !send(ctx, out, p) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// CombineLatest emits the latest value of both sides whenever either side
+// sends, once each side has sent at least once. It keeps going while one
+// side is open, reusing the last value of the closed one, and closes when
+// both are closed, or as soon as a side closes without ever sending since
+// no pair could be formed.
+func CombineLatest[A, B any](ctx context.Context, a <-chan A, b <-chan B) <-chan Pair[A, B] {
+	out := make(chan Pair[A, B])
+	go func() {
+		defer close(out)
+		var (
+			p          Pair[A, B]
+			gotA, gotB bool
+		)
+		for a != nil || b != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-a:
+				if !ok {
+					if !gotA {
+						return
+					}
+					a = nil
+					continue
+				}
+				p.Left, gotA = v, true
+			case v, ok := <-b:
+				if !ok {
+					if !gotB {
+						return
+					}
+					b = nil
+					continue
+				}
+				p.Right, gotB = v, true
+			}
+			if gotA && gotB && !send(ctx, out, p) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// WithLatestFrom emits a pair for every value of a, with the latest value
+// of b. Values of a that arrive before b sent anything are dropped. It
+// closes when a closes; when b closes its last value keeps being used.
+func WithLatestFrom[A, B any](ctx context.Context, a <-chan A, b <-chan B) <-chan Pair[A, B] {
+	out := make(chan Pair[A, B])
+	go func() {
+		defer close(out)
+		var (
+			latest B
+			gotB   bool
+		)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-a:
+				if !ok {
+					return
+				}
+				if gotB && !send(ctx, out, Pair[A, B]{v, latest}) {
+					return
+				}
+			case v, ok := <-b:
+				if !ok {
+					b = nil
+					continue
+				}
+				latest, gotB = v, true
+			}
+		}
+	}()
+	return out
+}
+
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
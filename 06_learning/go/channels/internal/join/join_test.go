@@ -0,0 +1,106 @@
+package join
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// emit sends vals on a new channel, sleeping before each, and closes it.
+func emit[T any](pause time.Duration, vals ...T) <-chan T {
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		for _, v := range vals {
+			time.Sleep(pause)
+			ch <- v
+		}
+	}()
+	return ch
+}
+
+// next receives one pair and fails the test if none comes within a second.
+func next[A, B any](t *testing.T, out <-chan Pair[A, B]) Pair[A, B] {
+	t.Helper()
+	select {
+	case p, ok := <-out:
+		if !ok {
+			t.Fatal("output closed early")
+		}
+		return p
+	case <-time.After(time.Second):
+		t.Fatal("no pair within a second")
+	}
+	return Pair[A, B]{}
+}
+
+func TestZipPairsByPosition(t *testing.T) {
+	// NOTE: the sides run at different speeds and lengths, pairs still match up by position
+	out := Zip(context.Background(), emit(5*time.Millisecond, 1, 2, 3, 4), emit(0, "a", "b", "c"))
+	var got []Pair[int, string]
+	for p := range out {
+		got = append(got, p)
+	}
+	want := []Pair[int, string]{{1, "a"}, {2, "b"}, {3, "c"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Zip = %v, want %v", got, want)
+	}
+}
+
+func TestCombineLatest(t *testing.T) {
+	a, b := make(chan int), make(chan string)
+	out := CombineLatest(context.Background(), a, b)
+
+	// NOTE: unbuffered sides, each send is handled before the next one is accepted
+	a <- 1
+	a <- 2
+	b <- "x"
+	if p := next(t, out); p != (Pair[int, string]{2, "x"}) {
+		t.Errorf("first pair = %v, want the latest of both {2 x}", p)
+	}
+	b <- "y"
+	if p := next(t, out); p != (Pair[int, string]{2, "y"}) {
+		t.Errorf("after b sent = %v, want {2 y}", p)
+	}
+	close(b)
+	a <- 3
+	if p := next(t, out); p != (Pair[int, string]{3, "y"}) {
+		t.Errorf("after b closed = %v, want its last value kept {3 y}", p)
+	}
+	close(a)
+	if p, ok := <-out; ok {
+		t.Errorf("got %v after both sides closed, want the output closed", p)
+	}
+}
+
+func TestWithLatestFrom(t *testing.T) {
+	a, b := make(chan int), make(chan string)
+	out := WithLatestFrom(context.Background(), a, b)
+
+	a <- 1 // dropped, b has not sent yet
+	b <- "x"
+	b <- "y"
+	a <- 2
+	if p := next(t, out); p != (Pair[int, string]{2, "y"}) {
+		t.Errorf("pair = %v, want {2 y}", p)
+	}
+	close(a)
+	if p, ok := <-out; ok {
+		t.Errorf("got %v after a closed, want the output closed", p)
+	}
+}
+
+func TestCancelClosesTheOutput(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := Zip(ctx, make(chan int), make(chan int))
+	cancel()
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Error("got a pair from sides that never sent")
+		}
+	case <-time.After(time.Second):
+		t.Error("output still open a second after cancel")
+	}
+}
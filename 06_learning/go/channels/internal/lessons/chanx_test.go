@@ -0,0 +1,55 @@
+package lessons
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestChanxBuildsMatchTheSpec runs both builds of every lesson that keeps
+// a chanx copy of its main.go and matches each against the documented
+// output, so the hand-maintained copies cannot drift apart.
+func TestChanxBuildsMatchTheSpec(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs lessons")
+	}
+	root, err := ModuleRoot(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, l := range all {
+		if !l.HasCode() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(root, l.Dir, "main_chanx.go")); err != nil {
+			continue
+		}
+		t.Run(l.ID(), func(t *testing.T) {
+			t.Parallel()
+			spec, ok, err := Spec(root, l)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				t.Skip("no expected output documented")
+			}
+			builds := map[string][]string{"plain": nil, "chanx": {"-tags", "chanx"}}
+			for name, flags := range builds {
+				runner := &Runner{Root: root, BinDir: t.TempDir(), Defaults: true, BuildFlags: flags}
+				res, err := runner.Run(context.Background(), l)
+				if err != nil {
+					t.Fatalf("%s build: %v", name, err)
+				}
+				if v := Compare(l, spec, res.Output); v.Status != Pass || res.ExitCode != 0 {
+					t.Errorf("%s build exited with %d and does not match %s:\n%s", name, res.ExitCode, l.Doc, v.Diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package lessons
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const generatedHeader = "// Code generated by \"go run ./cmd/lessons embed\"; DO NOT EDIT.\n\n"
+
+// Embed turns every lesson with code into an importable package below dir,
+// a directory relative to root, so a program can run lessons without
+// building them. Each copy is the lesson's main package without its chanx
+// build, with main renamed to Main. Embed also writes a registry package
+// in dir listing the lessons. The result maps file paths relative to dir
+// to their content.
+func Embed(root string, all []Lesson, dir string) (map[string][]byte, error) {
+	module, err := modulePath(root)
+	if err != nil {
+		return nil, err
+	}
+	importPath := path.Join(module, filepath.ToSlash(dir))
+	files := map[string][]byte{}
+	var registry bytes.Buffer
+	var imports, entries []string
+	for _, l := range all {
+		if !l.HasCode() {
+			continue
+		}
+		// NOTE: the playground lists lessons by title, an empty one would be a blank entry
+		if l.Title == "" {
+			return nil, fmt.Errorf("lesson %s has no title, add it to the README table or give it a doc", l.ID())
+		}
+		pkg := filepath.Base(l.Dir)
+		if err := embedPackage(root, l, pkg, files); err != nil {
+			return nil, err
+		}
+		imports = append(imports, strconv.Quote(path.Join(importPath, pkg)))
+		entries = append(entries, fmt.Sprintf("{ID: %q, Title: %q, Main: %s.Main},", l.ID(), l.Title, pkg))
+	}
+
+	registry.WriteString(generatedHeader)
+	fmt.Fprintf(&registry, "// Package %s holds a copy of every lesson as an importable package.\n", path.Base(importPath))
+	fmt.Fprintf(&registry, "package %s\n\nimport (\n%s\n)\n\n", path.Base(importPath), strings.Join(imports, "\n"))
+	registry.WriteString("// Lesson is a lesson that can be run by calling Main.\n")
+	registry.WriteString("type Lesson struct {\n\tID    string\n\tTitle string\n\tMain  func()\n}\n\n")
+	fmt.Fprintf(&registry, "// Lessons are the lessons in order.\nvar Lessons = []Lesson{\n%s\n}\n", strings.Join(entries, "\n"))
+	src, err := format.Source(registry.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format the registry, %w", err)
+	}
+	files[path.Base(importPath)+".go"] = src
+	return files, nil
+}
+
+// modulePath reads the module path from the go.mod in root.
+func modulePath(root string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	for line := range strings.SplitSeq(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`), nil
+		}
+	}
+	return "", fmt.Errorf("no module path in %s", filepath.Join(root, "go.mod"))
+}
+
+// embedPackage adds the files of lesson l, rewritten as package pkg, to files.
+func embedPackage(root string, l Lesson, pkg string, files map[string][]byte) error {
+	dir := filepath.Join(root, l.Dir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read lesson %s, %w", l.ID(), err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		// NOTE: the default build context leaves out the chanx build of the lesson
+		if ok, err := build.Default.MatchFile(dir, name); err != nil || !ok {
+			continue
+		}
+		src, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		out, err := embedFile(name, src, pkg)
+		if err != nil {
+			return fmt.Errorf("failed to embed lesson %s, %w", l.ID(), err)
+		}
+		files[path.Join(pkg, name)] = out
+	}
+	return nil
+}
+
+// embedFile rewrites one file of a lesson's main package.
+func embedFile(name string, src []byte, pkg string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	type edit struct {
+		offset int
+		old    string
+		new    string
+	}
+	edits := []edit{{fset.Position(f.Name.Pos()).Offset, "main", pkg}}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if fn, ok := n.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "main" {
+			edits = append(edits, edit{fset.Position(fn.Name.Pos()).Offset, "main", "Main"})
+		}
+		return true
+	})
+
+	slices.SortFunc(edits, func(a, b edit) int { return b.offset - a.offset })
+	out := slices.Clone(src)
+	for _, e := range edits {
+		out = slices.Concat(out[:e.offset], []byte(e.new), out[e.offset+len(e.old):])
+	}
+	// NOTE: build constraints and anything else above the package clause are dropped
+	out = append([]byte(generatedHeader), out[fset.Position(f.Package).Offset:]...)
+	return format.Source(out)
+}
@@ -0,0 +1,152 @@
+// Package lessons discovers the lessons of this module and runs them.
+//
+// A lesson is identified by its number and an optional letter suffix
+// ("13b"). The code lives in cmd/lessonNNN or cmd/lesson_NNN and the
+// markdown lives either next to main.go or in docs/.
+package lessons
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Lesson is a single lesson found in the module.
+type Lesson struct {
+	Number int
+	Suffix string
+	Title  string
+	// Dir is the package directory relative to the module root, e.g.
+	// "cmd/lesson007". Empty when the lesson only has a doc.
+	Dir string
+	// Doc is the markdown file relative to the module root. Empty when
+	// the lesson has no doc.
+	Doc string
+}
+
+// ID returns the short identifier used on the command line, e.g. "7" or "13b".
+func (l Lesson) ID() string {
+	return strconv.Itoa(l.Number) + l.Suffix
+}
+
+// HasCode reports whether the lesson has a runnable main package.
+func (l Lesson) HasCode() bool {
+	return l.Dir != ""
+}
+
+var (
+	dirPattern = regexp.MustCompile(`^lesson_?(\d+)([a-z]?)$`)
+	docPattern = regexp.MustCompile(`^lessons?_?(\d+)([a-z]?)\.md$`)
+	idPattern  = regexp.MustCompile(`^(?:lesson_?)?0*(\d+)([a-z]?)$`)
+)
+
+// Discover returns every lesson under root, ordered by number and suffix.
+func Discover(root string) ([]Lesson, error) {
+	byID := map[string]*Lesson{}
+	get := func(num int, suffix string) *Lesson {
+		l := Lesson{Number: num, Suffix: suffix}
+		if existing, ok := byID[l.ID()]; ok {
+			return existing
+		}
+		byID[l.ID()] = &l
+		return &l
+	}
+
+	entries, err := os.ReadDir(filepath.Join(root, "cmd"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cmd dir, %w", err)
+	}
+	for _, entry := range entries {
+		m := dirPattern.FindStringSubmatch(entry.Name())
+		if !entry.IsDir() || m == nil {
+			continue
+		}
+		num, _ := strconv.Atoi(m[1])
+		l := get(num, m[2])
+		dir := filepath.Join("cmd", entry.Name())
+		if _, err := os.Stat(filepath.Join(root, dir, "main.go")); err == nil {
+			l.Dir = dir
+		}
+		doc := filepath.Join(dir, entry.Name()+".md")
+		if _, err := os.Stat(filepath.Join(root, doc)); err == nil {
+			l.Doc = doc
+		}
+	}
+
+	docs, err := os.ReadDir(filepath.Join(root, "docs"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read docs dir, %w", err)
+	}
+	for _, entry := range docs {
+		m := docPattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || m == nil {
+			continue
+		}
+		num, _ := strconv.Atoi(m[1])
+		l := get(num, m[2])
+		if l.Doc == "" {
+			l.Doc = filepath.Join("docs", entry.Name())
+		}
+	}
+
+	titles, err := readmeTitles(filepath.Join(root, "README.md"))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Lesson, 0, len(byID))
+	for id, l := range byID {
+		l.Title = titles[id]
+		if l.Title == "" && l.Doc != "" {
+			l.Title, err = docTitle(filepath.Join(root, l.Doc))
+			if err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, *l)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Number != result[j].Number {
+			return result[i].Number < result[j].Number
+		}
+		return result[i].Suffix < result[j].Suffix
+	})
+	return result, nil
+}
+
+// Find returns the lesson matching id. Accepted forms are "7", "007",
+// "13b", "lesson007" and "lesson_019b".
+func Find(lessons []Lesson, id string) (Lesson, error) {
+	number, suffix, err := ParseID(id)
+	if err != nil {
+		return Lesson{}, err
+	}
+	want := Lesson{Number: number, Suffix: suffix}.ID()
+	for _, l := range lessons {
+		if l.ID() == want {
+			return l, nil
+		}
+	}
+	return Lesson{}, fmt.Errorf("lesson %q not found", id)
+}
+
+// ModuleRoot walks up from dir until it finds a go.mod file.
+func ModuleRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no go.mod found above %s", dir)
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,148 @@
+package lessons
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	readmeRow   = regexp.MustCompile(`^\|\s*(\d+[a-z]?)\s*\|\s*(.*?)\s*\|\s*$`)
+	titlePrefix = regexp.MustCompile(`(?i)^(?:here's the core concept for )?lesson\s+\d+[a-z]?\s*[:\-]*\s*`)
+	expectedRe  = regexp.MustCompile(`(?i)expected output`)
+)
+
+// readmeTitles maps lesson ids to the topics listed in the README table.
+func readmeTitles(path string) (map[string]string, error) {
+	titles := map[string]string{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return titles, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read README, %w", err)
+	}
+	for line := range strings.SplitSeq(string(data), "\n") {
+		if m := readmeRow.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			titles[m[1]] = m[2]
+		}
+	}
+	return titles, nil
+}
+
+// docTitle returns the first heading of a lesson doc without the
+// "Lesson N:" prefix.
+func docTitle(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read lesson doc, %w", err)
+	}
+	for line := range strings.SplitSeq(string(data), "\n") {
+		if heading, ok := strings.CutPrefix(line, "# "); ok {
+			title := titlePrefix.ReplaceAllString(strings.TrimSpace(heading), "")
+			return strings.TrimSuffix(title, ":"), nil
+		}
+	}
+	return "", nil
+}
+
+// ExpectedOutput extracts the lines documented under the "Expected output"
+// marker of a lesson doc. It understands the three shapes used in the
+// docs: a fenced block after the marker, a marker inside a fenced block,
+// and plain lines after the marker up to the next blank line. ok is false
+// when the doc does not document any output.
+func ExpectedOutput(doc []byte) (lines []string, ok bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(doc))
+	var all []string
+	for scanner.Scan() {
+		all = append(all, strings.TrimRight(scanner.Text(), " \t\r"))
+	}
+
+	inFence := false
+	for i, line := range all {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if !expectedRe.MatchString(line) {
+			continue
+		}
+		if inFence {
+			return collectFence(all[i+1:]), true
+		}
+		rest := all[i+1:]
+		for len(rest) > 0 && rest[0] == "" {
+			rest = rest[1:]
+		}
+		if len(rest) == 0 {
+			return nil, false
+		}
+		if strings.HasPrefix(strings.TrimSpace(rest[0]), "```") {
+			return collectFence(rest[1:]), true
+		}
+		for _, l := range rest {
+			if l == "" || strings.HasPrefix(l, "#") {
+				break
+			}
+			lines = append(lines, l)
+		}
+		return lines, len(lines) > 0
+	}
+	return nil, false
+}
+
+func collectFence(rest []string) []string {
+	var lines []string
+	for _, l := range rest {
+		if strings.HasPrefix(strings.TrimSpace(l), "```") {
+			break
+		}
+		lines = append(lines, l)
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// SetSection replaces the body of the "## heading" section of a doc, or
+// appends the section when the doc does not have it yet.
+func SetSection(doc []byte, heading, body string) []byte {
+	lines := strings.Split(strings.TrimRight(string(doc), "\n"), "\n")
+	title := "## " + heading
+
+	start := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == title {
+			start = i
+			break
+		}
+	}
+	section := []string{title, "", strings.TrimRight(body, "\n")}
+	if start < 0 {
+		lines = append(lines, "")
+		lines = append(lines, section...)
+		return []byte(strings.Join(lines, "\n") + "\n")
+	}
+
+	end := len(lines)
+	inFence := false
+	for i := start + 1; i < len(lines); i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), "```") {
+			inFence = !inFence
+		}
+		if !inFence && strings.HasPrefix(lines[i], "## ") {
+			end = i
+			break
+		}
+	}
+	rest := lines[end:]
+	if len(rest) > 0 {
+		section = append(section, "")
+	}
+	lines = append(append(lines[:start:start], section...), rest...)
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
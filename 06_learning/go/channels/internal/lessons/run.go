@@ -0,0 +1,157 @@
+package lessons
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"channelspractice/internal/config"
+)
+
+// Runner builds lesson binaries and executes them.
+type Runner struct {
+	Root string
+	// BinDir is where built binaries are placed. Defaults to a temp dir.
+	BinDir string
+	// Timeout bounds a single run. When it expires the lesson receives
+	// SIGINT, and is killed if it has not exited after Grace.
+	Timeout time.Duration
+	Grace   time.Duration
+	// BuildFlags are passed to go build, e.g. "-race".
+	BuildFlags []string
+	// Env is appended to the environment of the lesson process.
+	Env []string
+	// Dir is the working directory of the lesson process, Root by default.
+	Dir string
+	// Defaults drops the config.EnvPrefix variables of the caller, so the
+	// lesson runs with the parameters its documented output was taken with.
+	Defaults bool
+	// Stdout receives the lesson output as it is produced, in addition to
+	// the captured Result.Output.
+	Stdout io.Writer
+}
+
+// Result describes a finished lesson run.
+type Result struct {
+	Output   []byte
+	Stderr   []byte
+	ExitCode int
+	TimedOut bool
+	Duration time.Duration
+}
+
+// Build compiles the lesson and returns the path of the binary.
+func (r *Runner) Build(ctx context.Context, l Lesson) (string, error) {
+	if !l.HasCode() {
+		return "", fmt.Errorf("lesson %s has no code", l.ID())
+	}
+	if r.BinDir == "" {
+		dir, err := os.MkdirTemp("", "lessons-")
+		if err != nil {
+			return "", err
+		}
+		r.BinDir = dir
+	}
+	bin := filepath.Join(r.BinDir, filepath.Base(l.Dir))
+	args := append([]string{"build", "-o", bin}, r.BuildFlags...)
+	args = append(args, "./"+filepath.ToSlash(l.Dir))
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = r.Root
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to build lesson %s, %w\n%s", l.ID(), err, out)
+	}
+	return bin, nil
+}
+
+// Run builds the lesson and executes it once.
+func (r *Runner) Run(ctx context.Context, l Lesson) (Result, error) {
+	bin, err := r.Build(ctx, l)
+	if err != nil {
+		return Result{}, err
+	}
+	return r.Exec(ctx, bin)
+}
+
+// Exec runs an already built lesson binary.
+func (r *Runner) Exec(ctx context.Context, bin string, args ...string) (Result, error) {
+	timeout := r.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	grace := r.Grace
+	if grace == 0 {
+		grace = 2 * time.Second
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(bin, args...)
+	cmd.Dir = r.Root
+	if r.Dir != "" {
+		cmd.Dir = r.Dir
+	}
+	env := os.Environ()
+	if r.Defaults {
+		env = slices.DeleteFunc(env, func(kv string) bool { return strings.HasPrefix(kv, config.EnvPrefix) })
+	}
+	cmd.Env = append(env, r.Env...)
+	cmd.Stdout = &stdout
+	if r.Stdout != nil {
+		cmd.Stdout = io.MultiWriter(&stdout, r.Stdout)
+	}
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return Result{}, fmt.Errorf("failed to start %s, %w", bin, err)
+	}
+	waitDone := make(chan error, 1)
+	go func() {
+		waitDone <- cmd.Wait()
+	}()
+
+	res := Result{}
+	var waitErr error
+	select {
+	case waitErr = <-waitDone:
+	case <-time.After(timeout):
+		res.TimedOut = true
+		waitErr = interrupt(cmd, waitDone, grace)
+	case <-ctx.Done():
+		waitErr = interrupt(cmd, waitDone, grace)
+	}
+	res.Duration = time.Since(start)
+	res.Output = stdout.Bytes()
+	res.Stderr = stderr.Bytes()
+
+	var exitErr *exec.ExitError
+	switch {
+	case waitErr == nil:
+	case errors.As(waitErr, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+	default:
+		return res, waitErr
+	}
+	return res, ctx.Err()
+}
+
+// interrupt sends SIGINT so lessons listening for signals can shut down
+// gracefully, then kills the process if it does not exit in time.
+func interrupt(cmd *exec.Cmd, waitDone <-chan error, grace time.Duration) error {
+	_ = cmd.Process.Signal(syscall.SIGINT)
+	select {
+	case err := <-waitDone:
+		return err
+	case <-time.After(grace):
+		_ = cmd.Process.Kill()
+		return <-waitDone
+	}
+}
@@ -0,0 +1,225 @@
+package lessons
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// Layout returns the canonical code directory and doc path of a lesson:
+// cmd/lessonNNN/main.go next to cmd/lessonNNN/lessonNNN.md.
+func Layout(number int, suffix string) (dir, doc string) {
+	name := fmt.Sprintf("lesson%03d%s", number, suffix)
+	dir = filepath.Join("cmd", name)
+	return dir, filepath.Join(dir, name+".md")
+}
+
+// NextNumber returns the number following the highest existing lesson.
+func NextNumber(all []Lesson) int {
+	next := 1
+	for _, l := range all {
+		next = max(next, l.Number+1)
+	}
+	return next
+}
+
+// ParseID splits a lesson id such as "13b" into its number and suffix.
+func ParseID(id string) (int, string, error) {
+	m := idPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(id)))
+	if m == nil {
+		return 0, "", fmt.Errorf("invalid lesson id %q", id)
+	}
+	var number int
+	fmt.Sscanf(m[1], "%d", &number)
+	return number, m[2], nil
+}
+
+// New scaffolds a lesson in the canonical layout: a stub main.go, the
+// lesson doc, a test skeleton and a row in the README table. When the
+// lesson already has a doc without code, the doc is moved into place
+// instead of generating a new one. It returns the created files.
+func New(root string, all []Lesson, number int, suffix, title string) ([]string, error) {
+	l := Lesson{Number: number, Suffix: suffix, Title: title}
+	for _, existing := range all {
+		if existing.ID() != l.ID() {
+			continue
+		}
+		if existing.HasCode() {
+			return nil, fmt.Errorf("lesson %s already exists in %s", l.ID(), existing.Dir)
+		}
+		if l.Title == "" {
+			l.Title = existing.Title
+		}
+		l.Doc = existing.Doc
+	}
+	if l.Title == "" {
+		return nil, fmt.Errorf("lesson %s needs a title", l.ID())
+	}
+
+	dir, doc := Layout(number, suffix)
+	if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+		return nil, err
+	}
+
+	data := struct {
+		ID      string
+		Title   string
+		DocName string
+		Name    string
+	}{l.ID(), l.Title, filepath.Base(doc), filepath.Base(dir)}
+
+	files := [][2]string{
+		{filepath.Join(dir, "main.go"), "main.go.tmpl"},
+		{filepath.Join(dir, "main_test.go"), "main_test.go.tmpl"},
+	}
+	if l.Doc == "" {
+		files = append(files, [2]string{doc, "lesson.md.tmpl"})
+	}
+
+	var created []string
+	for _, f := range files {
+		if err := render(filepath.Join(root, f[0]), f[1], data); err != nil {
+			return created, err
+		}
+		created = append(created, f[0])
+	}
+	if l.Doc != "" && l.Doc != doc {
+		if err := move(root, l.Doc, doc); err != nil {
+			return created, err
+		}
+		created = append(created, doc)
+	}
+
+	if err := addReadmeRow(filepath.Join(root, "README.md"), l.ID(), l.Title); err != nil {
+		return created, err
+	}
+	return created, nil
+}
+
+func render(path, name string, data any) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+	tmpl, err := template.ParseFS(templates, "templates/"+name)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return fmt.Errorf("failed to render %s, %w", name, err)
+	}
+	return os.WriteFile(path, b.Bytes(), 0o644)
+}
+
+func move(root, from, to string) error {
+	if _, err := os.Stat(filepath.Join(root, to)); err == nil {
+		return fmt.Errorf("cannot move %s, %s already exists", from, to)
+	}
+	if err := os.MkdirAll(filepath.Join(root, filepath.Dir(to)), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(root, from), filepath.Join(root, to))
+}
+
+// addReadmeRow inserts a lesson into the README summary table, keeping
+// the rows ordered and the column widths of the table.
+func addReadmeRow(path, id, title string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read README, %w", err)
+	}
+	lines := strings.Split(string(data), "\n")
+
+	var idWidth, titleWidth int
+	insertAt := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "| ---") {
+			cols := strings.Split(strings.Trim(trimmed, "|"), "|")
+			if len(cols) == 2 {
+				idWidth, titleWidth = len(strings.TrimSpace(cols[0])), len(strings.TrimSpace(cols[1]))
+			}
+			insertAt = i + 1
+			continue
+		}
+		m := readmeRow.FindStringSubmatch(trimmed)
+		if m == nil {
+			continue
+		}
+		if m[1] == id {
+			return nil
+		}
+		if compareIDs(m[1], id) < 0 {
+			insertAt = i + 1
+		}
+	}
+	if insertAt < 0 {
+		return fmt.Errorf("no lesson table found in %s", path)
+	}
+
+	row := fmt.Sprintf("| %-*s | %-*s |", idWidth, id, titleWidth, title)
+	lines = append(lines[:insertAt], append([]string{row}, lines[insertAt:]...)...)
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644)
+}
+
+func compareIDs(a, b string) int {
+	na, sa, _ := ParseID(a)
+	nb, sb, _ := ParseID(b)
+	if na != nb {
+		return na - nb
+	}
+	return strings.Compare(sa, sb)
+}
+
+// Move is a single rename of the layout migration.
+type Move struct {
+	From, To string
+}
+
+// MigrationPlan lists the renames that bring every lesson with code into
+// the canonical layout. Lessons that only have a doc are left alone, they
+// move when scaffolded with New.
+func MigrationPlan(root string, all []Lesson) ([]Move, error) {
+	var moves []Move
+	for _, l := range all {
+		if !l.HasCode() {
+			continue
+		}
+		dir, doc := Layout(l.Number, l.Suffix)
+		if l.Dir != dir {
+			if _, err := os.Stat(filepath.Join(root, dir)); err == nil {
+				return nil, fmt.Errorf("cannot move %s, %s already exists", l.Dir, dir)
+			}
+			moves = append(moves, Move{From: l.Dir, To: dir})
+		}
+		if l.Doc == "" {
+			continue
+		}
+		// NOTE: a doc next to main.go travels with its directory
+		from := l.Doc
+		if strings.HasPrefix(from, l.Dir+string(filepath.Separator)) {
+			from = filepath.Join(dir, filepath.Base(from))
+		}
+		if from != doc {
+			moves = append(moves, Move{From: from, To: doc})
+		}
+	}
+	return moves, nil
+}
+
+// Migrate applies a migration plan in order.
+func Migrate(root string, moves []Move) error {
+	for _, m := range moves {
+		if err := move(root, m.From, m.To); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package lessons
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"channelspractice/internal/chanx"
+	"channelspractice/internal/expect"
+)
+
+// StressConfig controls a stress session of one lesson.
+type StressConfig struct {
+	Runs     int
+	Parallel int
+	// Seed is the seed of the first run, run i uses Seed+i.
+	Seed uint64
+	// Procs are the GOMAXPROCS values picked from by seed.
+	Procs []int
+	// Yield is the probability of a yield at each chanx operation.
+	Yield float64
+	// Spec, when set, must match the output of every run.
+	Spec *expect.Spec
+}
+
+// StressRun is the outcome of a single run under stress.
+type StressRun struct {
+	Seed   uint64
+	Procs  int
+	Result Result
+	// Failure is empty for a passing run.
+	Failure string
+}
+
+// ProcsFor returns the GOMAXPROCS value a seed runs with, so a failing run
+// can be replayed from its seed alone.
+func ProcsFor(seed uint64, procs []int) int {
+	if len(procs) == 0 {
+		return 1
+	}
+	return procs[seed%uint64(len(procs))]
+}
+
+// Stress runs a built lesson binary cfg.Runs times, each with its own
+// seed and GOMAXPROCS, and calls report for every finished run.
+func (r *Runner) Stress(ctx context.Context, bin string, cfg StressConfig, report func(StressRun)) {
+	seeds := make(chan uint64)
+	go func() {
+		defer close(seeds)
+		for i := range cfg.Runs {
+			select {
+			case <-ctx.Done():
+				return
+			case seeds <- cfg.Seed + uint64(i):
+			}
+		}
+	}()
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for range max(1, cfg.Parallel) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seed := range seeds {
+				run := r.StressOnce(ctx, bin, seed, cfg)
+				mu.Lock()
+				report(run)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// StressOnce runs the binary once with the perturbation derived from seed,
+// in a temporary directory of its own so parallel runs never share files.
+func (r *Runner) StressOnce(ctx context.Context, bin string, seed uint64, cfg StressConfig) StressRun {
+	procs := ProcsFor(seed, cfg.Procs)
+	run := StressRun{Seed: seed, Procs: procs}
+	dir, err := os.MkdirTemp("", "lessons-run-")
+	if err != nil {
+		run.Failure = err.Error()
+		return run
+	}
+	defer os.RemoveAll(dir)
+
+	runner := *r
+	runner.Dir = dir
+	runner.Stdout = nil
+	runner.Env = append(append([]string(nil), r.Env...),
+		"GOMAXPROCS="+strconv.Itoa(procs),
+		chanx.SeedEnv+"="+strconv.FormatUint(seed, 10),
+		chanx.YieldEnv+"="+strconv.FormatFloat(cfg.Yield, 'f', -1, 64),
+	)
+
+	res, err := runner.Exec(ctx, bin)
+	run.Result = res
+	switch {
+	case err != nil:
+		run.Failure = err.Error()
+	case bytes.Contains(res.Stderr, []byte("WARNING: DATA RACE")):
+		run.Failure = "data race"
+	case res.TimedOut:
+		run.Failure = "timed out, possible deadlock"
+	case res.ExitCode != 0:
+		run.Failure = fmt.Sprintf("exited with code %d", res.ExitCode)
+	case cfg.Spec != nil:
+		if m := cfg.Spec.Match(SplitLines(res.Output)); !m.OK {
+			run.Failure = "output does not match spec\n" + m.Report()
+		}
+	}
+	return run
+}
@@ -0,0 +1,86 @@
+package lessons
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"channelspractice/internal/expect"
+)
+
+// Status is the outcome of verifying a lesson.
+type Status string
+
+const (
+	Pass Status = "PASS"
+	Fail Status = "FAIL"
+	Skip Status = "SKIP"
+)
+
+// Verdict is the outcome of comparing a lesson run with its doc.
+type Verdict struct {
+	Lesson Lesson
+	Status Status
+	// Reason explains a skip or a failure that is not an output mismatch.
+	Reason string
+	// Diff explains where the output stopped matching the spec.
+	Diff string
+}
+
+// Spec returns the output spec documented for the lesson.
+func Spec(root string, l Lesson) (*expect.Spec, bool, error) {
+	if l.Doc == "" {
+		return nil, false, nil
+	}
+	data, err := os.ReadFile(filepath.Join(root, l.Doc))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read lesson doc, %w", err)
+	}
+	spec, ok, err := SpecFromDoc(data)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid expect block in %s, %w", l.Doc, err)
+	}
+	return spec, ok, nil
+}
+
+// SpecFromDoc returns the output spec of a lesson doc. A ```expect block
+// takes precedence; otherwise the plain "Expected output" lines are
+// matched literally, with "..." standing for any lines.
+func SpecFromDoc(doc []byte) (*expect.Spec, bool, error) {
+	if src, ok := expect.Extract(doc); ok {
+		spec, err := expect.Parse(src)
+		if err != nil {
+			return nil, false, err
+		}
+		return spec, true, nil
+	}
+	lines, ok := ExpectedOutput(doc)
+	if !ok {
+		return nil, false, nil
+	}
+	return expect.FromLines(lines), true, nil
+}
+
+// Compare matches the actual output against the spec. Trailing whitespace
+// and trailing blank lines are ignored.
+func Compare(l Lesson, spec *expect.Spec, output []byte) Verdict {
+	res := spec.Match(SplitLines(output))
+	if !res.OK {
+		return Verdict{Lesson: l, Status: Fail, Diff: res.Report()}
+	}
+	return Verdict{Lesson: l, Status: Pass}
+}
+
+// SplitLines splits output into lines with trailing whitespace removed.
+func SplitLines(output []byte) []string {
+	text := strings.TrimRight(string(output), "\n\r\t ")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return lines
+}
@@ -0,0 +1,99 @@
+package limit
+
+import (
+	"math"
+	"time"
+)
+
+// AIMD is additive increase, multiplicative decrease, the TCP congestion
+// control rule: the limit grows by one per window of successful calls and
+// is cut by Backoff on every drop or call slower than Timeout. It only
+// reacts to overload, so it settles just below the point where the
+// downstream starts failing. It grows only while at least half the limit
+// is in flight: calls to a mostly idle downstream say nothing about how
+// much more it could take.
+type AIMD struct {
+	// Backoff multiplies the limit on a drop, 0.9 by default.
+	Backoff float64
+	// Timeout turns slow calls into drops, 0 disables it.
+	Timeout time.Duration
+}
+
+func (a *AIMD) Update(limit float64, s Sample) float64 {
+	backoff := a.Backoff
+	if backoff <= 0 || backoff >= 1 {
+		backoff = 0.9
+	}
+	if s.Dropped || (a.Timeout > 0 && s.RTT > a.Timeout) {
+		return limit * backoff
+	}
+	if float64(s.InFlight)*2 >= limit {
+		return limit + 1/limit
+	}
+	return limit
+}
+
+// Gradient compares the latency of each call with the latency of the
+// downstream without load, the smallest latency seen. While they are
+// close there is no queueing and the limit grows by a small queue
+// allowance; once calls get slower than Tolerance times the no-load
+// latency the ratio drops below one and shrinks the limit proportionally.
+// It reacts to latency before anything fails. This is the gradient
+// algorithm of Netflix's concurrency-limits.
+//
+// The no-load latency can only be measured when nothing queues, so every
+// ProbeEvery samples the limit drops to the queue allowance for a moment
+// and the minimum is measured again; otherwise a downstream that got
+// slower for good would look overloaded forever. While less than half the
+// limit is in use the latency only shows an unloaded downstream, so the
+// limit is left as it is.
+type Gradient struct {
+	// Tolerance is how much slower than the no-load latency a call may be
+	// before the limit shrinks, 2 by default.
+	Tolerance float64
+	// Smoothing weighs each new limit against the old one, 0.05 by default.
+	Smoothing float64
+	// ProbeEvery is the number of samples between probes, 1000 by default.
+	ProbeEvery int
+
+	minRTT  float64
+	samples int
+}
+
+func (g *Gradient) Update(limit float64, s Sample) float64 {
+	tolerance, smoothing, probeEvery := g.Tolerance, g.Smoothing, g.ProbeEvery
+	if tolerance < 1 {
+		tolerance = 2
+	}
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = 0.05
+	}
+	if probeEvery <= 0 {
+		probeEvery = 1000
+	}
+
+	queue := math.Sqrt(limit)
+	g.samples++
+	if g.samples%probeEvery == 0 {
+		g.minRTT = 0
+		return max(queue, 1)
+	}
+
+	rtt := float64(s.RTT)
+	if rtt <= 0 {
+		return limit
+	}
+	if g.minRTT == 0 || rtt < g.minRTT {
+		g.minRTT = rtt
+	}
+	if s.Dropped {
+		return limit * 0.5
+	}
+	if float64(s.InFlight)*2 < limit {
+		return limit
+	}
+
+	gradient := math.Max(0.5, math.Min(1, tolerance*g.minRTT/rtt))
+	next := limit*gradient + queue
+	return limit*(1-smoothing) + next*smoothing
+}
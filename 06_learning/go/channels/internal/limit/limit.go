@@ -0,0 +1,170 @@
+// Package limit is an adaptive concurrency limiter. Where lesson 14 uses a
+// buffered channel of fixed size 3 as a semaphore, a Limiter moves its size
+// up and down from the latency and errors of the calls it admits: it grows
+// while calls come back fast and shrinks when latency rises or calls are
+// dropped, which is the sign that the downstream is queueing.
+//
+// The algorithm deciding the new limit is pluggable, AIMD and Gradient
+// follow Netflix's concurrency-limits library.
+//
+//	lim := limit.New(&limit.Gradient{}, limit.Options{})
+//	tok, err := lim.Acquire(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	go func() {
+//		if err := call(); err != nil {
+//			tok.Drop()
+//			return
+//		}
+//		tok.Done()
+//	}()
+package limit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Sample is the outcome of one call, fed to the algorithm.
+type Sample struct {
+	RTT time.Duration
+	// InFlight is the number of calls in flight when this one started.
+	InFlight int
+	// Dropped is set when the call failed or timed out because of load.
+	Dropped bool
+}
+
+// Algorithm computes the next limit from the current one and a sample.
+type Algorithm interface {
+	Update(limit float64, s Sample) float64
+}
+
+// Options bound the limit. Zero values get defaults.
+type Options struct {
+	// Initial is the starting limit, 10 by default.
+	Initial int
+	// Min and Max bound the limit, 1 and 1000 by default.
+	Min, Max int
+	// Now replaces the clock, for simulations. time.Now by default.
+	Now func() time.Time
+}
+
+// Limiter admits calls while fewer than Limit are in flight.
+type Limiter struct {
+	alg      Algorithm
+	min, max float64
+	now      func() time.Time
+
+	mu       sync.Mutex
+	limit    float64
+	inflight int
+	// changed is closed and replaced whenever a slot may have opened.
+	changed chan struct{}
+}
+
+// New returns a limiter using alg.
+func New(alg Algorithm, opts Options) *Limiter {
+	if opts.Initial <= 0 {
+		opts.Initial = 10
+	}
+	if opts.Min <= 0 {
+		opts.Min = 1
+	}
+	if opts.Max <= 0 {
+		opts.Max = 1000
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Limiter{
+		alg:     alg,
+		min:     float64(opts.Min),
+		max:     float64(opts.Max),
+		now:     opts.Now,
+		limit:   float64(opts.Initial),
+		changed: make(chan struct{}),
+	}
+}
+
+// Token is an admitted call. Exactly one of Done, Drop or Ignore must be
+// called when the call finishes.
+type Token struct {
+	l        *Limiter
+	start    time.Time
+	inflight int
+	once     sync.Once
+}
+
+// Acquire blocks until a call may start or ctx is done.
+func (l *Limiter) Acquire(ctx context.Context) (*Token, error) {
+	for {
+		tok, ok, changed := l.tryAcquire()
+		if ok {
+			return tok, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// TryAcquire admits a call if the limit allows it right now.
+func (l *Limiter) TryAcquire() (*Token, bool) {
+	tok, ok, _ := l.tryAcquire()
+	return tok, ok
+}
+
+func (l *Limiter) tryAcquire() (*Token, bool, <-chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight >= int(l.limit) {
+		return nil, false, l.changed
+	}
+	l.inflight++
+	return &Token{l: l, start: l.now(), inflight: l.inflight}, true, nil
+}
+
+// Limit returns the current limit.
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// InFlight returns the number of admitted calls not finished yet.
+func (l *Limiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inflight
+}
+
+// Done reports a successful call; its latency feeds the algorithm.
+func (t *Token) Done() { t.release(true, false) }
+
+// Drop reports a call that failed because of load, e.g. a timeout or a
+// 503. The algorithm backs off.
+func (t *Token) Drop() { t.release(true, true) }
+
+// Ignore releases the slot without a sample, for calls that failed for
+// reasons unrelated to load.
+func (t *Token) Ignore() { t.release(false, false) }
+
+func (t *Token) release(sample, dropped bool) {
+	t.once.Do(func() {
+		l := t.l
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.inflight--
+		if sample {
+			next := l.alg.Update(l.limit, Sample{RTT: l.now().Sub(t.start), InFlight: t.inflight, Dropped: dropped})
+			l.limit = math.Max(l.min, math.Min(l.max, next))
+		}
+		close(l.changed)
+		l.changed = make(chan struct{})
+	})
+}
@@ -0,0 +1,126 @@
+package limit
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+const (
+	base    = 10 * time.Millisecond
+	timeout = 100 * time.Millisecond
+	phase   = 20 * time.Second
+)
+
+// capacities are the concurrent calls the downstream handles at base
+// latency in each phase.
+var capacities = []int{20, 10}
+
+// TestAIMDConverges checks that AIMD settles between the capacity and the
+// load where calls get slower than its Timeout of twice the base latency,
+// twice the capacity: its sawtooth averages about halfway.
+func TestAIMDConverges(t *testing.T) {
+	averages := simulate(t, &AIMD{Timeout: 2 * base})
+	for i, c := range capacities {
+		checkNear(t, i, averages[i], 1.5*float64(c))
+	}
+}
+
+// TestGradientConverges checks that Gradient settles where latency is
+// Tolerance (2) times the no-load latency, twice the capacity, plus its
+// queue allowance of the square root of the limit.
+func TestGradientConverges(t *testing.T) {
+	averages := simulate(t, &Gradient{})
+	for i, c := range capacities {
+		checkNear(t, i, averages[i], 2*float64(c)+math.Sqrt(2*float64(c)))
+	}
+}
+
+// checkNear fails unless the average limit of phase i is within 15% of
+// want.
+func checkNear(t *testing.T, i int, avg, want float64) {
+	t.Helper()
+	if math.Abs(avg-want) > 0.15*want {
+		t.Errorf("phase %d: average limit %.1f, want %.1f ± 15%%", i+1, avg, want)
+	}
+}
+
+// simulate runs alg against a downstream with a fixed number of cores: up
+// to the capacity of the phase, concurrent calls take base latency, beyond
+// that they share the cores and every call slows down proportionally. Calls slower than
+// timeout fail. Clients offer far more load than the downstream can take,
+// so only the limiter keeps the latency in check. After the first phase
+// the capacity halves, as if a replica went away.
+//
+// The simulation runs on a virtual clock in 1ms ticks, so it is fast and
+// deterministic. It returns the average limit over the last fifth of each
+// phase.
+func simulate(t *testing.T, alg Algorithm) []float64 {
+	rng := rand.New(rand.NewPCG(1, 0))
+	now := time.Unix(0, 0)
+	lim := New(alg, Options{Initial: 1, Now: func() time.Time { return now }})
+
+	type call struct {
+		tok     *Token
+		finish  time.Time
+		dropped bool
+	}
+	var (
+		inflight    []call
+		averages    []float64
+		done, drops int
+	)
+	const tick = time.Millisecond
+	for i, c := range capacities {
+		var limits []int
+		for end := now.Add(phase); now.Before(end); {
+			now = now.Add(tick)
+
+			// NOTE: complete calls in finish order so the limiter sees the same sequence a real client would
+			slices.SortFunc(inflight, func(a, b call) int { return a.finish.Compare(b.finish) })
+			n := 0
+			for _, call := range inflight {
+				if call.finish.After(now) {
+					inflight[n] = call
+					n++
+					continue
+				}
+				if call.dropped {
+					call.tok.Drop()
+					drops++
+				} else {
+					call.tok.Done()
+					done++
+				}
+			}
+			inflight = inflight[:n]
+
+			for {
+				tok, ok := lim.TryAcquire()
+				if !ok {
+					break
+				}
+				load := float64(len(inflight)+1) / float64(c)
+				latency := time.Duration(float64(base) * max(1, load) * (0.9 + 0.2*rng.Float64()))
+				call := call{tok: tok, finish: now.Add(latency)}
+				if latency > timeout {
+					call.finish, call.dropped = now.Add(timeout), true
+				}
+				inflight = append(inflight, call)
+			}
+			limits = append(limits, lim.Limit())
+		}
+
+		tail := limits[len(limits)*4/5:]
+		sum := 0
+		for _, l := range tail {
+			sum += l
+		}
+		averages = append(averages, float64(sum)/float64(len(tail)))
+		t.Logf("phase %d: capacity %d, average limit %.1f", i+1, c, averages[i])
+	}
+	t.Logf("%d calls done, %d dropped", done, drops)
+	return averages
+}
@@ -0,0 +1,131 @@
+// Package logx sets up log/slog for the lessons and tools. Workers and
+// stages log with the same attribute keys, so the output can be filtered
+// by worker, stage, job or attempt whatever lesson produced it:
+//
+//	log := logx.From(ctx).With(logx.Worker(id))
+//	log.Info("processing", logx.Job(job))
+//
+// The handler is text by default and JSON with LOG_FORMAT=json; LOG_LEVEL
+// takes debug, info, warn or error.
+//
+// A logger travels in the context. WithCorrelationID tags it with an ID,
+// and as long as the context travels with the work item across channels,
+// every stage that logs through From(ctx) repeats that ID.
+package logx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+const (
+	// FormatEnv selects the handler, text or json.
+	FormatEnv = "LOG_FORMAT"
+	// LevelEnv sets the minimum level.
+	LevelEnv = "LOG_LEVEL"
+)
+
+// Attribute keys shared by every lesson.
+const (
+	WorkerKey      = "worker"
+	StageKey       = "stage"
+	JobKey         = "job"
+	AttemptKey     = "attempt"
+	CorrelationKey = "correlation_id"
+)
+
+// Worker, Stage, Job, Attempt and Correlation build the shared attributes.
+func Worker(id int) slog.Attr         { return slog.Int(WorkerKey, id) }
+func Stage(name string) slog.Attr     { return slog.String(StageKey, name) }
+func Job(id any) slog.Attr            { return slog.Any(JobKey, id) }
+func Attempt(n int) slog.Attr         { return slog.Int(AttemptKey, n) }
+func Correlation(id string) slog.Attr { return slog.String(CorrelationKey, id) }
+
+// New returns a logger writing to w in format, text or json.
+func New(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(format) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q, want text or json", format)
+}
+
+// FromEnv returns a logger writing to stdout, configured by FormatEnv and
+// LevelEnv, and installs it as slog's default. Invalid values fall back
+// to text at info level with a warning.
+func FromEnv() *slog.Logger {
+	return FromEnvTo(os.Stdout)
+}
+
+// FromEnvTo is FromEnv writing to w, e.g. stderr for a lesson whose stdout
+// is its plain output.
+func FromEnvTo(w io.Writer) *slog.Logger {
+	var level slog.Level
+	levelErr := level.UnmarshalText([]byte(os.Getenv(LevelEnv)))
+	if os.Getenv(LevelEnv) == "" {
+		levelErr = nil
+	}
+	log, err := New(w, os.Getenv(FormatEnv), level)
+	if err != nil {
+		log, _ = New(w, "text", level)
+		log.Warn("ignoring "+FormatEnv, "err", err)
+	}
+	if levelErr != nil {
+		log.Warn("ignoring "+LevelEnv, "err", levelErr)
+	}
+	slog.SetDefault(log)
+	return log
+}
+
+type ctxKey struct{}
+
+type ctxValue struct {
+	log *slog.Logger
+	id  string
+}
+
+// With returns ctx carrying log.
+func With(ctx context.Context, log *slog.Logger) context.Context {
+	v, _ := ctx.Value(ctxKey{}).(ctxValue)
+	v.log = log
+	return context.WithValue(ctx, ctxKey{}, v)
+}
+
+// From returns the logger carried by ctx, or slog's default.
+func From(ctx context.Context) *slog.Logger {
+	if v, ok := ctx.Value(ctxKey{}).(ctxValue); ok && v.log != nil {
+		return v.log
+	}
+	return slog.Default()
+}
+
+// WithCorrelationID returns ctx whose logger tags every record with id.
+// An empty id gets a random one.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		id = NewCorrelationID()
+	}
+	return context.WithValue(ctx, ctxKey{}, ctxValue{log: From(ctx).With(Correlation(id)), id: id})
+}
+
+// CorrelationID returns the ID set with WithCorrelationID, or "".
+func CorrelationID(ctx context.Context) string {
+	v, _ := ctx.Value(ctxKey{}).(ctxValue)
+	return v.id
+}
+
+// NewCorrelationID returns a random 8 byte hex ID.
+func NewCorrelationID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
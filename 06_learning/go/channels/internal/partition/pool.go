@@ -0,0 +1,184 @@
+package partition
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClosed is returned by Submit after Close.
+var ErrClosed = errors.New("pool closed")
+
+// Handler processes one job. Calls for the same key never overlap and
+// happen in submission order. A handler may submit more jobs with the ctx
+// it is given; jobs for its own worker need room in that worker's queue.
+type Handler[T any] func(ctx context.Context, worker int, key string, job T)
+
+type task[T any] struct {
+	key string
+	job T
+}
+
+// handlerKey marks the ctx given to handlers with their pool.
+type handlerKey struct{}
+
+// Pool is a key-partitioned worker pool.
+type Pool[T any] struct {
+	ctx      context.Context
+	handle   Handler[T]
+	buffer   int
+	replicas int
+	// resizeMu serializes Resize calls.
+	resizeMu sync.Mutex
+
+	// mu guards the fields below. It is never held while waiting for
+	// jobs, so handlers can always submit.
+	mu     sync.RWMutex
+	ring   *Ring
+	queues []chan task[T]
+	exited []chan struct{}
+	// resized is closed when the running Resize has switched rings, nil
+	// when no Resize is running.
+	resized chan struct{}
+	closed  bool
+	// pending counts jobs submitted and not yet handled.
+	pending sync.WaitGroup
+}
+
+// NewPool starts workers goroutines. Every worker has its own queue of
+// buffer jobs; Submit blocks while the queue of the key's worker is full.
+func NewPool[T any](ctx context.Context, workers, buffer int, handle Handler[T]) *Pool[T] {
+	p := &Pool[T]{handle: handle, buffer: buffer, replicas: DefaultReplicas}
+	p.ctx = context.WithValue(ctx, handlerKey{}, p)
+	p.ring = NewRing(workers, p.replicas)
+	for w := range workers {
+		p.start(w)
+	}
+	return p
+}
+
+func (p *Pool[T]) start(w int) {
+	queue := make(chan task[T], p.buffer)
+	exited := make(chan struct{})
+	p.queues = append(p.queues, queue)
+	p.exited = append(p.exited, exited)
+	go func() {
+		defer close(exited)
+		for t := range queue {
+			p.handle(p.ctx, w, t.key, t.job)
+			p.pending.Done()
+		}
+	}()
+}
+
+// Submit queues job on the worker that owns key. While the pool is resized
+// it waits, except for jobs submitted by a handler with its ctx, which
+// Resize lets through so it can drain.
+func (p *Pool[T]) Submit(ctx context.Context, key string, job T) error {
+	queue, err := p.route(ctx, key)
+	if err != nil {
+		return err
+	}
+	select {
+	case queue <- task[T]{key, job}:
+		return nil
+	case <-ctx.Done():
+		p.pending.Done()
+		return ctx.Err()
+	}
+}
+
+// route returns the queue of the worker that owns key and counts the job
+// as pending, so Resize and Close wait for it to be handled.
+func (p *Pool[T]) route(ctx context.Context, key string) (chan<- task[T], error) {
+	fromHandler := ctx.Value(handlerKey{}) == p
+	for {
+		p.mu.RLock()
+		if p.closed {
+			p.mu.RUnlock()
+			return nil, ErrClosed
+		}
+		if resized := p.resized; resized != nil && !fromHandler {
+			p.mu.RUnlock()
+			select {
+			case <-resized:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		p.pending.Add(1)
+		queue := p.queues[p.ring.Owner(key)]
+		p.mu.RUnlock()
+		return queue, nil
+	}
+}
+
+// Owner returns the worker key is currently routed to.
+func (p *Pool[T]) Owner(key string) int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.ring.Owner(key)
+}
+
+// Resize changes the number of workers. It waits until every queued job
+// is handled before switching to the new ring: a key that moves could
+// otherwise run on its new worker while older jobs for it are still queued
+// on the old one. Submit blocks meanwhile, except from handlers.
+func (p *Pool[T]) Resize(workers int) {
+	p.resizeMu.Lock()
+	defer p.resizeMu.Unlock()
+	p.mu.Lock()
+	if p.closed || workers < 1 {
+		p.mu.Unlock()
+		return
+	}
+	resized := make(chan struct{})
+	p.resized = resized
+	p.mu.Unlock()
+	defer close(resized)
+
+	// NOTE: drain without the lock, handlers that submit would block on it and never finish
+	p.pending.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resized = nil
+	if p.closed {
+		return
+	}
+	for w := len(p.queues); w < workers; w++ {
+		p.start(w)
+	}
+	// NOTE: the removed workers are idle, nothing is pending and only handlers could submit
+	for w := workers; w < len(p.queues); w++ {
+		close(p.queues[w])
+		<-p.exited[w]
+	}
+	p.queues, p.exited = p.queues[:workers], p.exited[:workers]
+	p.ring = NewRing(workers, p.replicas)
+}
+
+// Close stops accepting jobs and waits until the queued ones are handled.
+// Handlers submitting after Close get ErrClosed.
+func (p *Pool[T]) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	// NOTE: jobs routed before closed was set may still be on their way into a queue
+	p.pending.Wait()
+	p.mu.Lock()
+	queues, exited := p.queues, p.exited
+	p.mu.Unlock()
+	for w := range queues {
+		close(queues[w])
+	}
+	for w := range exited {
+		<-exited[w]
+	}
+}
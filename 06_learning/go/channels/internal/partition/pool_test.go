@@ -0,0 +1,73 @@
+package partition
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOrderAcrossResize(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		last = map[string]int{}
+	)
+	pool := NewPool(context.Background(), 2, 1, func(ctx context.Context, worker int, key string, seq int) {
+		mu.Lock()
+		defer mu.Unlock()
+		if seq != last[key]+1 {
+			t.Errorf("%s: job %d after %d", key, seq, last[key])
+		}
+		last[key] = seq
+	})
+	for seq := 1; seq <= 20; seq++ {
+		for k := range 8 {
+			if err := pool.Submit(context.Background(), "key-"+strconv.Itoa(k), seq); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if seq%5 == 0 {
+			pool.Resize(1 + seq%3)
+		}
+	}
+	pool.Close()
+	if err := pool.Submit(context.Background(), "key-0", 21); err != ErrClosed {
+		t.Errorf("Submit after Close = %v, want ErrClosed", err)
+	}
+}
+
+// TestResizeWithSubmittingHandler checks that Resize drains a pool whose
+// handlers submit follow-up jobs instead of deadlocking on them.
+func TestResizeWithSubmittingHandler(t *testing.T) {
+	var pool *Pool[int]
+	started := make(chan struct{})
+	var once sync.Once
+	pool = NewPool(context.Background(), 2, 1, func(ctx context.Context, worker int, key string, n int) {
+		once.Do(func() { close(started) })
+		if n == 0 {
+			return
+		}
+		// NOTE: give Resize time to start waiting for the pool to drain
+		time.Sleep(10 * time.Millisecond)
+		if err := pool.Submit(ctx, key, n-1); err != nil {
+			t.Errorf("Submit from handler: %v", err)
+		}
+	})
+	if err := pool.Submit(context.Background(), "a", 3); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pool.Resize(3)
+		pool.Close()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Resize did not return, deadlock")
+	}
+}
@@ -0,0 +1,89 @@
+// Package partition is a worker pool that routes jobs to workers by key.
+// Jobs with the same key always go to the same worker, which handles them
+// one at a time in submission order, while different keys run in
+// parallel. Compare the shared jobs channel of lessons 7, 16 and 18, where
+// any worker can pick any job.
+//
+// Keys are assigned with consistent hashing: every worker owns many points
+// on a hash ring and a key belongs to the first point after its hash.
+// Adding or removing a worker only moves the keys of the points it gains
+// or loses, about 1/n of them, instead of almost all of them as with
+// hash(key) % n.
+package partition
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// DefaultReplicas is the number of ring points per worker. More points
+// spread the keys more evenly.
+const DefaultReplicas = 128
+
+// Ring maps keys to workers 0..n-1.
+type Ring struct {
+	points []point
+}
+
+type point struct {
+	hash   uint64
+	worker int
+}
+
+// NewRing returns a ring for workers with replicas points each.
+func NewRing(workers, replicas int) *Ring {
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+	r := &Ring{points: make([]point, 0, workers*replicas)}
+	for w := range workers {
+		for i := range replicas {
+			// NOTE: points depend only on the worker id, so worker w keeps its points when others come and go
+			r.points = append(r.points, point{hash("worker-" + strconv.Itoa(w) + "#" + strconv.Itoa(i)), w})
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i].hash < r.points[j].hash })
+	return r
+}
+
+// Owner returns the worker responsible for key.
+func (r *Ring) Owner(key string) int {
+	if len(r.points) == 0 {
+		return 0
+	}
+	h := hash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].worker
+}
+
+// Moved returns how many of keys have a different owner in to than in from.
+func Moved(from, to *Ring, keys []string) int {
+	moved := 0
+	for _, k := range keys {
+		if from.Owner(k) != to.Owner(k) {
+			moved++
+		}
+	}
+	return moved
+}
+
+// Modulo returns the worker of key with hash(key) % workers, the
+// assignment consistent hashing replaces.
+func Modulo(key string, workers int) int {
+	return int(hash(key) % uint64(workers))
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	// NOTE: FNV clusters similar short strings, a final mix spreads them over the ring
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,167 @@
+// Package pause lets an operator stop the intake of generators, worker
+// pools and pipeline stages for a while without cancelling them. Unlike a
+// cancelled context, a paused pipeline keeps its goroutines, channels and
+// state, and carries on where it stopped once resumed.
+//
+// Goroutines observe the controller by calling Wait before they take the
+// next item. Work already taken is finished, so pausing drains the
+// pipeline instead of freezing it mid-item:
+//
+//	for {
+//		if err := ctrl.Wait(ctx); err != nil {
+//			return
+//		}
+//		job := <-jobs
+//		...
+//	}
+package pause
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// State is the state of a controller.
+type State int
+
+const (
+	Running State = iota
+	Paused
+)
+
+func (s State) String() string {
+	if s == Paused {
+		return "paused"
+	}
+	return "running"
+}
+
+// Controller is a pause switch shared by any number of goroutines. A nil
+// *Controller is always running, so it can be an optional field.
+type Controller struct {
+	mu sync.Mutex
+	// resumed is closed while running and open while paused.
+	resumed  chan struct{}
+	pausedAt time.Time
+	total    time.Duration
+}
+
+// New returns a running controller.
+func New() *Controller {
+	resumed := make(chan struct{})
+	close(resumed)
+	return &Controller{resumed: resumed}
+}
+
+// Pause stops intake. It reports false when already paused.
+func (c *Controller) Pause() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.pausedAt.IsZero() {
+		return false
+	}
+	c.pausedAt = time.Now()
+	c.resumed = make(chan struct{})
+	return true
+}
+
+// Resume releases every goroutine blocked in Wait. It reports false when
+// not paused.
+func (c *Controller) Resume() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pausedAt.IsZero() {
+		return false
+	}
+	c.total += time.Since(c.pausedAt)
+	c.pausedAt = time.Time{}
+	close(c.resumed)
+	return true
+}
+
+// Toggle pauses a running controller and resumes a paused one.
+func (c *Controller) Toggle() State {
+	if c.Pause() {
+		return Paused
+	}
+	c.Resume()
+	return Running
+}
+
+// Wait returns immediately while running and blocks while paused, until
+// the controller is resumed or ctx is done.
+func (c *Controller) Wait(ctx context.Context) error {
+	if c == nil {
+		return ctx.Err()
+	}
+	c.mu.Lock()
+	resumed := c.resumed
+	c.mu.Unlock()
+	select {
+	case <-resumed:
+		return ctx.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// State returns whether the controller is running or paused.
+func (c *Controller) State() State {
+	if c == nil {
+		return Running
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pausedAt.IsZero() {
+		return Running
+	}
+	return Paused
+}
+
+// PausedFor returns the total time spent paused, including the current
+// pause.
+func (c *Controller) PausedFor() time.Duration {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	total := c.total
+	if !c.pausedAt.IsZero() {
+		total += time.Since(c.pausedAt)
+	}
+	return total
+}
+
+// OnSignals pauses the controller on SIGUSR1 and resumes it on SIGUSR2
+// until ctx is done, calling notify (if not nil) after every change:
+//
+//	kill -USR1 <pid>   # pause
+//	kill -USR2 <pid>   # resume
+func OnSignals(ctx context.Context, c *Controller, notify func(State)) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		defer signal.Stop(sigs)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-sigs:
+				changed := false
+				if sig == syscall.SIGUSR1 {
+					changed = c.Pause()
+				} else {
+					changed = c.Resume()
+				}
+				if changed && notify != nil {
+					notify(c.State())
+				}
+			}
+		}
+	}()
+}
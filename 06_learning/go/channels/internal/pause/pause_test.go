@@ -0,0 +1,77 @@
+package pause
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitBlocksWhilePaused(t *testing.T) {
+	c := New()
+	ctx := context.Background()
+	if err := c.Wait(ctx); err != nil {
+		t.Fatalf("Wait while running = %v", err)
+	}
+
+	if !c.Pause() || c.Pause() {
+		t.Fatal("Pause should report true once, then false while paused")
+	}
+	const waiters = 3
+	released := make(chan error, waiters)
+	for range waiters {
+		go func() { released <- c.Wait(ctx) }()
+	}
+	select {
+	case err := <-released:
+		t.Fatalf("Wait returned %v while paused", err)
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	if !c.Resume() || c.Resume() {
+		t.Fatal("Resume should report true once, then false while running")
+	}
+	for range waiters {
+		select {
+		case err := <-released:
+			if err != nil {
+				t.Errorf("Wait after Resume = %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("a waiter is still blocked after Resume")
+		}
+	}
+	if d := c.PausedFor(); d < 30*time.Millisecond {
+		t.Errorf("PausedFor = %v, want at least the 30ms paused", d)
+	}
+}
+
+func TestCancelReleasesAPausedWait(t *testing.T) {
+	c := New()
+	c.Pause()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := c.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait = %v, want the context error", err)
+	}
+	if s := c.State(); s != Paused {
+		t.Errorf("State after the wait gave up = %v, want paused", s)
+	}
+}
+
+func TestToggle(t *testing.T) {
+	c := New()
+	if s := c.Toggle(); s != Paused {
+		t.Errorf("first Toggle = %v, want paused", s)
+	}
+	if s := c.Toggle(); s != Running || c.State() != Running {
+		t.Errorf("second Toggle = %v, want running", s)
+	}
+}
+
+func TestNilControllerIsRunning(t *testing.T) {
+	var c *Controller
+	if err := c.Wait(context.Background()); err != nil || c.State() != Running {
+		t.Errorf("nil controller Wait = %v, State = %v, want running", err, c.State())
+	}
+}
@@ -0,0 +1,317 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"channelspractice/internal/chanx"
+	"channelspractice/internal/pause"
+)
+
+// Pipeline is a validated spec ready to run. Channels are chanx channels
+// named after the stages, so the recorder and the stepper work on it too.
+type Pipeline struct {
+	nodes []node
+	// OnSkip is called for every value dropped by a stage with the Skip
+	// policy, possibly from several workers at once. It may be nil.
+	OnSkip func(stage string, v any, err error)
+	// Pause, when set, holds sources before every value and map and sink
+	// workers before they take the next one. Values already taken finish.
+	Pause *pause.Controller
+}
+
+type node struct {
+	Stage
+	source SourceFunc
+	mapper MapFunc
+	sink   SinkFunc
+}
+
+// Build validates spec against reg and prepares the pipeline. A
+// *ValidationError lists every problem found, not just the first.
+func Build(spec Spec, reg *Registry) (*Pipeline, error) {
+	order, err := validate(spec, reg)
+	if err != nil {
+		return nil, err
+	}
+	p := &Pipeline{}
+	for _, s := range order {
+		n := node{Stage: s}
+		if n.source, n.mapper, n.sink, err = reg.instantiate(s); err != nil {
+			return nil, fmt.Errorf("stage %q: %w", s.Name, err)
+		}
+		p.nodes = append(p.nodes, n)
+	}
+	return p, nil
+}
+
+// Run starts every stage and waits until the sinks have drained their
+// inputs. The first error of a stage with the Fail policy cancels the
+// rest of the pipeline and is returned.
+func (p *Pipeline) Run(ctx context.Context) error {
+	g, ctx := errgroup.WithContext(ctx)
+
+	// NOTE: every edge gets its own channel, a tee writes to several of them
+	edges := map[[2]string]*chanx.Chan[any]{}
+	for _, n := range p.nodes {
+		for _, in := range n.Inputs {
+			from := p.node(in)
+			name := in
+			if from.Type == Tee {
+				name = in + "->" + n.Name
+			}
+			edges[[2]string{in, n.Name}] = chanx.Make[any](name, from.Buffer)
+		}
+	}
+	inputs := func(n node) []*chanx.Chan[any] {
+		var chans []*chanx.Chan[any]
+		for _, in := range n.Inputs {
+			chans = append(chans, edges[[2]string{in, n.Name}])
+		}
+		return chans
+	}
+	outputs := func(n node) []*chanx.Chan[any] {
+		var chans []*chanx.Chan[any]
+		for _, other := range p.nodes {
+			if ch, ok := edges[[2]string{n.Name, other.Name}]; ok {
+				chans = append(chans, ch)
+			}
+		}
+		return chans
+	}
+
+	for _, n := range p.nodes {
+		ins, outs := inputs(n), outputs(n)
+		switch n.Type {
+		case Source:
+			p.runSource(ctx, g, n, outs[0])
+		case Map:
+			p.runMap(ctx, g, n, ins[0], outs[0])
+		case Sink:
+			p.runSink(ctx, g, n, ins[0])
+		case Merge:
+			runMerge(ctx, g, n, ins, outs[0])
+		case Tee:
+			runTee(ctx, g, n, ins[0], outs)
+		case Bridge:
+			runBridge(ctx, g, n, ins[0], outs[0])
+		}
+	}
+	return g.Wait()
+}
+
+func (p *Pipeline) node(name string) node {
+	for _, n := range p.nodes {
+		if n.Name == name {
+			return n
+		}
+	}
+	return node{}
+}
+
+// attempt calls fn up to 1+Retries times and applies the error policy. It
+// returns a non-nil error only when the pipeline must stop.
+func (p *Pipeline) attempt(ctx context.Context, n node, v any, fn func() error) (ok bool, err error) {
+	for try := 0; ; try++ {
+		err = fn()
+		if err == nil {
+			return true, nil
+		}
+		if ctx.Err() != nil {
+			return false, nil
+		}
+		if try >= n.Retries {
+			break
+		}
+	}
+	if n.OnError == Skip {
+		if p.OnSkip != nil {
+			p.OnSkip(n.Name, v, err)
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("stage %q: %w", n.Name, err)
+}
+
+func (p *Pipeline) runSource(ctx context.Context, g *errgroup.Group, n node, out *chanx.Chan[any]) {
+	g.Go(chanx.Named(n.Name, func() error {
+		defer out.Close()
+		emit := func(v any) error {
+			if err := p.Pause.Wait(ctx); err != nil {
+				return err
+			}
+			return out.SendCtx(ctx, v)
+		}
+		if err := n.source(ctx, emit); err != nil && ctx.Err() == nil {
+			if n.OnError == Skip {
+				// NOTE: a source cannot skip a single value, it just ends early
+				if p.OnSkip != nil {
+					p.OnSkip(n.Name, nil, err)
+				}
+				return nil
+			}
+			return fmt.Errorf("stage %q: %w", n.Name, err)
+		}
+		return nil
+	}))
+}
+
+func (p *Pipeline) runMap(ctx context.Context, g *errgroup.Group, n node, in, out *chanx.Chan[any]) {
+	var wg sync.WaitGroup
+	wg.Add(n.Workers)
+	for i := range n.Workers {
+		g.Go(chanx.Named(workerName(n, i), func() error {
+			defer wg.Done()
+			for {
+				if p.Pause.Wait(ctx) != nil {
+					return nil
+				}
+				v, ok, err := in.RecvCtx(ctx)
+				if err != nil || !ok {
+					return nil
+				}
+				var result any
+				sent, err := p.attempt(ctx, n, v, func() (err error) {
+					result, err = n.mapper(ctx, v)
+					return err
+				})
+				if err != nil {
+					return err
+				}
+				if !sent {
+					continue
+				}
+				if err := out.SendCtx(ctx, result); err != nil {
+					return nil
+				}
+			}
+		}))
+	}
+	chanx.Go(n.Name+" closer", func() {
+		defer out.Close()
+		wg.Wait()
+	})
+}
+
+func (p *Pipeline) runSink(ctx context.Context, g *errgroup.Group, n node, in *chanx.Chan[any]) {
+	for i := range n.Workers {
+		g.Go(chanx.Named(workerName(n, i), func() error {
+			for {
+				if p.Pause.Wait(ctx) != nil {
+					return nil
+				}
+				v, ok, err := in.RecvCtx(ctx)
+				if err != nil || !ok {
+					return nil
+				}
+				if _, err := p.attempt(ctx, n, v, func() error {
+					return n.sink(ctx, v)
+				}); err != nil {
+					return err
+				}
+			}
+		}))
+	}
+}
+
+func runMerge(ctx context.Context, g *errgroup.Group, n node, ins []*chanx.Chan[any], out *chanx.Chan[any]) {
+	var wg sync.WaitGroup
+	wg.Add(len(ins))
+	for _, in := range ins {
+		g.Go(chanx.Named(n.Name+" "+in.Name(), func() error {
+			defer wg.Done()
+			forward(ctx, in, out)
+			return nil
+		}))
+	}
+	chanx.Go(n.Name+" closer", func() {
+		defer out.Close()
+		wg.Wait()
+	})
+}
+
+func runTee(ctx context.Context, g *errgroup.Group, n node, in *chanx.Chan[any], outs []*chanx.Chan[any]) {
+	g.Go(chanx.Named(n.Name, func() error {
+		defer func() {
+			for _, out := range outs {
+				out.Close()
+			}
+		}()
+		for {
+			v, ok, err := in.RecvCtx(ctx)
+			if err != nil || !ok {
+				return nil
+			}
+			// NOTE: the slowest reader sets the pace, buffer the tee to absorb bursts
+			for _, out := range outs {
+				if err := out.SendCtx(ctx, v); err != nil {
+					return nil
+				}
+			}
+		}
+	}))
+}
+
+// runBridge drains every channel received on in into out, like lesson 22.
+func runBridge(ctx context.Context, g *errgroup.Group, n node, in, out *chanx.Chan[any]) {
+	// NOTE: the reader counts as one member so the closer cannot see zero before the first drain starts
+	var wg sync.WaitGroup
+	wg.Add(1)
+	g.Go(chanx.Named(n.Name, func() error {
+		defer wg.Done()
+		for {
+			v, ok, err := in.RecvCtx(ctx)
+			if err != nil || !ok {
+				return nil
+			}
+			ch, isChan := v.(<-chan any)
+			if !isChan {
+				return fmt.Errorf("stage %q: bridge input must be <-chan any, got %T", n.Name, v)
+			}
+			wg.Add(1)
+			chanx.Go(n.Name+" drain", func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case val, ok := <-ch:
+						if !ok {
+							return
+						}
+						if err := out.SendCtx(ctx, val); err != nil {
+							return
+						}
+					}
+				}
+			})
+		}
+	}))
+	chanx.Go(n.Name+" closer", func() {
+		defer out.Close()
+		wg.Wait()
+	})
+}
+
+func forward(ctx context.Context, in, out *chanx.Chan[any]) {
+	for {
+		v, ok, err := in.RecvCtx(ctx)
+		if err != nil || !ok {
+			return
+		}
+		if err := out.SendCtx(ctx, v); err != nil {
+			return
+		}
+	}
+}
+
+func workerName(n node, i int) string {
+	if n.Workers == 1 {
+		return n.Name
+	}
+	return n.Name + " " + strconv.Itoa(i)
+}
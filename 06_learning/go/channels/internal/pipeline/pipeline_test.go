@@ -0,0 +1,160 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// collector is a sink that keeps every value it receives.
+type collector struct {
+	mu   sync.Mutex
+	vals []int
+}
+
+func (c *collector) sorted() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Sorted(slices.Values(c.vals))
+}
+
+func testRegistry(c *collector) *Registry {
+	reg := NewRegistry()
+	reg.Source("range", func(args Args) (SourceFunc, error) {
+		to, err := args.Int("to", 0)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, emit func(any) error) error {
+			for i := range to {
+				if err := emit(i); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+	})
+	reg.Map("multiply", func(args Args) (MapFunc, error) {
+		by, err := args.Int("by", 1)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, v any) (any, error) { return v.(int) * by, nil }, nil
+	})
+	reg.Map("reject", func(args Args) (MapFunc, error) {
+		bad, err := args.Int("value", -1)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, v any) (any, error) {
+			if v.(int) == bad {
+				return nil, errors.New("rejected")
+			}
+			return v, nil
+		}, nil
+	})
+	reg.Sink("collect", func(Args) (SinkFunc, error) {
+		return func(ctx context.Context, v any) error {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.vals = append(c.vals, v.(int))
+			return nil
+		}, nil
+	})
+	return reg
+}
+
+// load writes spec to a file named name and loads it.
+func load(t *testing.T, name, spec string) Spec {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestRunYAMLSpec(t *testing.T) {
+	spec := load(t, "fan.yaml", `
+name: fan
+stages:
+  - {name: nums, type: source, func: range, args: {to: 5}}
+  - {name: copy, type: tee, inputs: [nums]}
+  - {name: double, type: map, func: multiply, args: {by: 2}, inputs: [copy], workers: 3}
+  - {name: triple, type: map, func: multiply, args: {by: 3}, inputs: [copy]}
+  - {name: both, type: merge, inputs: [double, triple]}
+  - {name: drop, type: map, func: reject, args: {value: 6}, inputs: [both], on_error: skip}
+  - {name: save, type: sink, func: collect, inputs: [drop]}
+`)
+	var c collector
+	p, err := Build(spec, testRegistry(&c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var skipped []any
+	p.OnSkip = func(stage string, v any, err error) { skipped = append(skipped, v) }
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// NOTE: 6 comes out of both branches, as 3*2 and 2*3, and is dropped both times
+	if want := []int{0, 0, 2, 3, 4, 8, 9, 12}; !slices.Equal(c.sorted(), want) {
+		t.Errorf("collected %v, want %v", c.sorted(), want)
+	}
+	if len(skipped) != 2 {
+		t.Errorf("skipped %v, want 6 twice", skipped)
+	}
+}
+
+func TestFailPolicyStopsTheRun(t *testing.T) {
+	spec := load(t, "fail.json", `{"name": "fail", "stages": [
+		{"name": "nums", "type": "source", "func": "range", "args": {"to": 100}},
+		{"name": "drop", "type": "map", "func": "reject", "args": {"value": 3}, "inputs": ["nums"]},
+		{"name": "save", "type": "sink", "func": "collect", "inputs": ["drop"]}
+	]}`)
+	var c collector
+	p, err := Build(spec, testRegistry(&c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Run(context.Background()); err == nil || !strings.Contains(err.Error(), `stage "drop"`) {
+		t.Errorf("Run = %v, want the error of stage drop", err)
+	}
+}
+
+func TestBuildListsEveryProblem(t *testing.T) {
+	spec := Spec{Name: "broken", Stages: []Stage{
+		{Name: "nums", Type: Source, Func: "missing"},
+		{Name: "double", Type: Map, Func: "multiply", Inputs: []string{"nums", "other"}},
+		{Name: "save", Type: Sink, Func: "collect", Inputs: []string{"double"}, OnError: "retry"},
+	}}
+	_, err := Build(spec, testRegistry(&collector{}))
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("Build = %v, want a *ValidationError", err)
+	}
+	want := []string{`unknown source function "missing"`, "exactly one input", `input "other" is not a stage`, `on_error must be`}
+	for _, w := range want {
+		if !strings.Contains(err.Error(), w) {
+			t.Errorf("validation error does not mention %q:\n%v", w, err)
+		}
+	}
+}
+
+func TestLoadRejectsUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "typo.yaml")
+	if err := os.WriteFile(path, []byte("name: typo\nstages:\n  - {name: nums, type: source, func: range, worker: 2}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load accepted the misspelled field worker")
+	}
+}
@@ -0,0 +1,93 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// SourceFunc produces values by calling emit, which fails once the
+// pipeline is cancelled.
+type SourceFunc func(ctx context.Context, emit func(any) error) error
+
+// MapFunc transforms a single value.
+type MapFunc func(ctx context.Context, v any) (any, error)
+
+// SinkFunc consumes a single value.
+type SinkFunc func(ctx context.Context, v any) error
+
+// Registry holds the Go functions a spec can refer to by name. Functions
+// are registered as constructors that receive the stage args, so a spec
+// with bad args is rejected before anything runs.
+type Registry struct {
+	sources map[string]func(Args) (SourceFunc, error)
+	maps    map[string]func(Args) (MapFunc, error)
+	sinks   map[string]func(Args) (SinkFunc, error)
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		sources: map[string]func(Args) (SourceFunc, error){},
+		maps:    map[string]func(Args) (MapFunc, error){},
+		sinks:   map[string]func(Args) (SinkFunc, error){},
+	}
+}
+
+// Source registers a function for source stages.
+func (r *Registry) Source(name string, f func(Args) (SourceFunc, error)) {
+	r.sources[name] = f
+}
+
+// Map registers a function for map stages.
+func (r *Registry) Map(name string, f func(Args) (MapFunc, error)) {
+	r.maps[name] = f
+}
+
+// Sink registers a function for sink stages.
+func (r *Registry) Sink(name string, f func(Args) (SinkFunc, error)) {
+	r.sinks[name] = f
+}
+
+// Names lists the registered functions of a stage type.
+func (r *Registry) Names(stageType string) []string {
+	switch stageType {
+	case Source:
+		return slices.Sorted(maps.Keys(r.sources))
+	case Map:
+		return slices.Sorted(maps.Keys(r.maps))
+	case Sink:
+		return slices.Sorted(maps.Keys(r.sinks))
+	}
+	return nil
+}
+
+// instantiate looks up and constructs the function of a stage.
+func (r *Registry) instantiate(s Stage) (source SourceFunc, mapper MapFunc, sink SinkFunc, err error) {
+	found := false
+	switch s.Type {
+	case Source:
+		var f func(Args) (SourceFunc, error)
+		if f, found = r.sources[s.Func]; found {
+			source, err = f(s.Args)
+		}
+	case Map:
+		var f func(Args) (MapFunc, error)
+		if f, found = r.maps[s.Func]; found {
+			mapper, err = f(s.Args)
+		}
+	case Sink:
+		var f func(Args) (SinkFunc, error)
+		if f, found = r.sinks[s.Func]; found {
+			sink, err = f(s.Args)
+		}
+	default:
+		return nil, nil, nil, nil
+	}
+	if !found {
+		return nil, nil, nil, fmt.Errorf("unknown %s function %q, registered: %s", s.Type, s.Func, strings.Join(r.Names(s.Type), ", "))
+	}
+	return source, mapper, sink, err
+}
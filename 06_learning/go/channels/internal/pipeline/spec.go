@@ -0,0 +1,164 @@
+// Package pipeline builds channel pipelines from a declarative spec, so the
+// shape of a pipeline like the ones in lessons 19 to 22 can be changed
+// without recompiling.
+//
+// A spec lists stages. Every stage has a type:
+//
+//	source  runs a registered source function, no inputs
+//	map     applies a registered function to every value of one input
+//	sink    consumes one input with a registered function, no output
+//	merge   forwards the values of two or more inputs to one output
+//	tee     copies every value of one input to each stage reading it
+//	bridge  flattens an input of channels (<-chan any) into one output
+//
+// map and sink stages fan out with workers > 1; every worker reads the same
+// input. The output of a stage may only be read by one stage, except for a
+// tee. A YAML example:
+//
+//	name: doubler
+//	stages:
+//	  - {name: nums, type: source, func: range, args: {to: 10}}
+//	  - {name: double, type: map, func: multiply, args: {by: 2}, inputs: [nums], workers: 3, buffer: 4}
+//	  - {name: save, type: sink, func: print, inputs: [double], on_error: skip}
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Stage types.
+const (
+	Source = "source"
+	Map    = "map"
+	Sink   = "sink"
+	Merge  = "merge"
+	Tee    = "tee"
+	Bridge = "bridge"
+)
+
+// Error policies.
+const (
+	// Fail stops the whole pipeline with the error, the default.
+	Fail = "fail"
+	// Skip drops the value and carries on.
+	Skip = "skip"
+)
+
+// Spec is a pipeline definition.
+type Spec struct {
+	Name   string  `yaml:"name" json:"name"`
+	Stages []Stage `yaml:"stages" json:"stages"`
+}
+
+// Stage is one node of the pipeline.
+type Stage struct {
+	Name string `yaml:"name" json:"name"`
+	Type string `yaml:"type" json:"type"`
+	// Func names a registered function, only for source, map and sink.
+	Func string `yaml:"func,omitempty" json:"func,omitempty"`
+	Args Args   `yaml:"args,omitempty" json:"args,omitempty"`
+	// Inputs names the stages whose output this stage reads.
+	Inputs []string `yaml:"inputs,omitempty" json:"inputs,omitempty"`
+	// Workers is the number of goroutines running Func, 1 when unset.
+	Workers int `yaml:"workers,omitempty" json:"workers,omitempty"`
+	// Buffer is the capacity of the stage's output channels.
+	Buffer int `yaml:"buffer,omitempty" json:"buffer,omitempty"`
+	// OnError is Fail or Skip. Map and sink stages first retry a failing
+	// value Retries times.
+	OnError string `yaml:"on_error,omitempty" json:"on_error,omitempty"`
+	Retries int    `yaml:"retries,omitempty" json:"retries,omitempty"`
+}
+
+// Load reads a spec from a .json, .yaml or .yml file. Unknown fields are
+// errors, so a misspelled option does not silently keep its default.
+func Load(path string) (Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Spec{}, fmt.Errorf("failed to read pipeline spec, %w", err)
+	}
+	var spec Spec
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&spec)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&spec)
+	default:
+		return Spec{}, fmt.Errorf("pipeline spec %s must be .json, .yaml or .yml", path)
+	}
+	if err != nil {
+		return Spec{}, fmt.Errorf("failed to parse pipeline spec %s, %w", path, err)
+	}
+	return spec, nil
+}
+
+// Args are the free-form arguments of a stage function.
+type Args map[string]any
+
+// Int returns the integer argument key, or def when it is not set.
+func (a Args) Int(key string, def int) (int, error) {
+	v, ok := a[key]
+	if !ok {
+		return def, nil
+	}
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case float64:
+		// NOTE: JSON numbers decode as float64
+		if n == float64(int(n)) {
+			return int(n), nil
+		}
+	}
+	return 0, fmt.Errorf("argument %q must be an integer, got %v", key, v)
+}
+
+// Duration returns the duration argument key, e.g. "100ms", or def when it
+// is not set.
+func (a Args) Duration(key string, def time.Duration) (time.Duration, error) {
+	v, ok := a[key]
+	if !ok {
+		return def, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("argument %q must be a duration like \"100ms\", got %v", key, v)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("argument %q must be a duration like \"100ms\", %w", key, err)
+	}
+	return d, nil
+}
+
+// Ints returns the integer list argument key, or def when it is not set.
+func (a Args) Ints(key string, def []int) ([]int, error) {
+	v, ok := a[key]
+	if !ok {
+		return def, nil
+	}
+	list, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("argument %q must be a list of integers, got %v", key, v)
+	}
+	ints := make([]int, 0, len(list))
+	for i := range list {
+		n, err := Args{key: list[i]}.Int(key, 0)
+		if err != nil {
+			return nil, err
+		}
+		ints = append(ints, n)
+	}
+	return ints, nil
+}
@@ -0,0 +1,163 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError lists every problem found in a spec.
+type ValidationError struct {
+	Spec     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid pipeline %q:\n  %s", e.Spec, strings.Join(e.Problems, "\n  "))
+}
+
+// validate checks the spec against the registry and returns the stages in
+// topological order, with defaults filled in.
+func validate(spec Spec, reg *Registry) ([]Stage, error) {
+	var problems []string
+	addf := func(stage, format string, args ...any) {
+		problems = append(problems, fmt.Sprintf("stage %q: ", stage)+fmt.Sprintf(format, args...))
+	}
+
+	if len(spec.Stages) == 0 {
+		problems = append(problems, "no stages defined")
+	}
+	stages := make([]Stage, 0, len(spec.Stages))
+	byName := map[string]Stage{}
+	for i, s := range spec.Stages {
+		if s.Name == "" {
+			problems = append(problems, fmt.Sprintf("stage %d: name is required", i+1))
+			continue
+		}
+		if _, dup := byName[s.Name]; dup {
+			addf(s.Name, "defined more than once")
+			continue
+		}
+		if s.Workers == 0 {
+			s.Workers = 1
+		}
+		if s.OnError == "" {
+			s.OnError = Fail
+		}
+		byName[s.Name] = s
+		stages = append(stages, s)
+	}
+
+	readers := map[string][]string{}
+	for _, s := range stages {
+		switch s.Type {
+		case Source, Map, Sink:
+			if s.Func == "" {
+				addf(s.Name, "%s stages need a func", s.Type)
+			} else if _, _, _, err := reg.instantiate(s); err != nil {
+				addf(s.Name, "%v", err)
+			}
+		case Merge, Tee, Bridge:
+			if s.Func != "" || len(s.Args) > 0 {
+				addf(s.Name, "%s stages take no func or args", s.Type)
+			}
+		case "":
+			addf(s.Name, "type is required, one of source, map, sink, merge, tee, bridge")
+		default:
+			addf(s.Name, "unknown type %q, want one of source, map, sink, merge, tee, bridge", s.Type)
+		}
+
+		switch {
+		case s.Type == Source && len(s.Inputs) > 0:
+			addf(s.Name, "source stages take no inputs")
+		case s.Type == Merge && len(s.Inputs) < 2:
+			addf(s.Name, "merge stages need at least two inputs, got %d", len(s.Inputs))
+		case s.Type != Source && s.Type != Merge && len(s.Inputs) != 1:
+			addf(s.Name, "%s stages need exactly one input, got %d", s.Type, len(s.Inputs))
+		}
+		for _, in := range s.Inputs {
+			from, ok := byName[in]
+			switch {
+			case !ok:
+				addf(s.Name, "input %q is not a stage", in)
+			case from.Type == Sink:
+				addf(s.Name, "input %q is a sink and has no output", in)
+			default:
+				readers[in] = append(readers[in], s.Name)
+			}
+		}
+
+		if s.Workers < 1 {
+			addf(s.Name, "workers must be at least 1, got %d", s.Workers)
+		}
+		if s.Workers > 1 && s.Type != Map && s.Type != Sink {
+			addf(s.Name, "only map and sink stages can have more than one worker")
+		}
+		if s.Buffer < 0 {
+			addf(s.Name, "buffer must not be negative, got %d", s.Buffer)
+		}
+		if s.Retries < 0 {
+			addf(s.Name, "retries must not be negative, got %d", s.Retries)
+		}
+		if s.Retries > 0 && s.Type != Map && s.Type != Sink {
+			addf(s.Name, "only map and sink stages can retry")
+		}
+		if s.OnError != Fail && s.OnError != Skip {
+			addf(s.Name, "on_error must be %q or %q, got %q", Fail, Skip, s.OnError)
+		}
+	}
+
+	for _, s := range stages {
+		r := readers[s.Name]
+		switch {
+		case s.Type == Sink:
+		case len(r) == 0:
+			addf(s.Name, "output is never read, add a sink")
+		case len(r) > 1 && s.Type != Tee:
+			addf(s.Name, "output is read by %s, put a tee in between to copy it or raise workers to share it", strings.Join(r, " and "))
+		}
+	}
+
+	order, cycle := sortStages(stages, byName)
+	if len(cycle) > 0 {
+		problems = append(problems, fmt.Sprintf("stages %s form or depend on a cycle", strings.Join(cycle, ", ")))
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Spec: spec.Name, Problems: problems}
+	}
+	return order, nil
+}
+
+// sortStages orders stages so every stage comes after its inputs. Stages
+// left over are part of a cycle.
+func sortStages(stages []Stage, byName map[string]Stage) (order []Stage, cycle []string) {
+	placed := map[string]bool{}
+	for len(order) < len(stages) {
+		progress := false
+		for _, s := range stages {
+			if placed[s.Name] {
+				continue
+			}
+			ready := true
+			for _, in := range s.Inputs {
+				if _, ok := byName[in]; ok && !placed[in] {
+					ready = false
+				}
+			}
+			if ready {
+				placed[s.Name] = true
+				order = append(order, s)
+				progress = true
+			}
+		}
+		if !progress {
+			break
+		}
+	}
+	for _, s := range stages {
+		if !placed[s.Name] {
+			cycle = append(cycle, s.Name)
+		}
+	}
+	return order, cycle
+}
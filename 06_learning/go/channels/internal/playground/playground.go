@@ -0,0 +1,322 @@
+// Package playground serves a web page that runs lessons and streams their
+// output to the browser over Server-Sent Events.
+//
+// The lessons are compiled into the server from the copies in package
+// programs, refreshed with "go run ./cmd/lessons embed", so the server
+// needs no Go toolchain. Each run is still a process of its own: the
+// server starts its own executable again with LessonEnv naming the lesson,
+// and RunChild, called first thing in main, runs it there. A lesson gets
+// its own stdout, arguments, signals and temporary working directory, the
+// "stop" button and the timeout send it a real SIGINT, and a lesson still
+// running a grace period later is killed.
+package playground
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"channelspractice/internal/playground/programs"
+)
+
+//go:generate go run ../../cmd/lessons embed
+
+//go:embed page.html
+var page string
+
+var pageTmpl = template.Must(template.New("page").Parse(page))
+
+// LessonEnv names the lesson a process started by the server runs.
+const LessonEnv = "PLAYGROUND_LESSON"
+
+// RunChild runs the lesson named by LessonEnv and exits, when the process
+// is one the server started for a run. Otherwise it returns at once.
+func RunChild() {
+	id, ok := os.LookupEnv(LessonEnv)
+	if !ok {
+		return
+	}
+	for _, l := range programs.Lessons {
+		if l.ID == id {
+			l.Main()
+			os.Exit(0)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown lesson %q\n", id)
+	os.Exit(2)
+}
+
+// Server is the playground HTTP handler.
+type Server struct {
+	timeout time.Duration
+	grace   time.Duration
+	byID    map[string]programs.Lesson
+	mux     *http.ServeMux
+
+	mu     sync.Mutex
+	nextID int
+	runs   map[string]context.CancelFunc
+}
+
+// New returns a server for the lessons in package programs. A lesson is
+// interrupted after timeout and killed if it is still running grace
+// later.
+func New(timeout, grace time.Duration) *Server {
+	s := &Server{
+		timeout: timeout,
+		grace:   grace,
+		byID:    map[string]programs.Lesson{},
+		mux:     http.NewServeMux(),
+		runs:    map[string]context.CancelFunc{},
+	}
+	for _, l := range programs.Lessons {
+		s.byID[l.ID] = l
+	}
+
+	s.mux.HandleFunc("GET /{$}", s.index)
+	s.mux.HandleFunc("GET /run/{lesson}", s.run)
+	s.mux.HandleFunc("POST /stop/{run}", s.stop)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// NOTE: any page open in the browser could otherwise start and stop lessons here
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin requests are not allowed", http.StatusForbidden)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// sameOrigin reports whether r comes from the playground page itself: the
+// Host is a loopback name, which rules out DNS rebinding, and the browser
+// did not mark the request as coming from another origin.
+func sameOrigin(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return false
+	}
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" && site != "none" {
+		return false
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != r.Host {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) index(w http.ResponseWriter, r *http.Request) {
+	data := struct {
+		Lessons []programs.Lesson
+		Timeout string
+	}{programs.Lessons, s.timeout.String()}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pageTmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// run executes a lesson and streams it as events: "start" with the run id,
+// one "line" per line of stdout, "stderr" with anything written to stderr
+// and a final "exit" describing how the lesson ended.
+func (s *Server) run(w http.ResponseWriter, r *http.Request) {
+	l, ok := s.byID[r.PathValue("lesson")]
+	if !ok {
+		http.Error(w, "unknown lesson", http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	// NOTE: a closed tab cancels the request context, which interrupts the lesson too
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	id := s.register(cancel)
+	defer s.unregister(id)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	stream := &eventStream{w: w, flusher: flusher}
+	stream.send("start", id)
+
+	out := &lineWriter{emit: func(line string) { stream.send("line", line) }}
+	var stderr bytes.Buffer
+	res, err := s.exec(ctx, l, out, &stderr)
+	out.Flush()
+	if stderr.Len() > 0 {
+		stream.send("stderr", stderr.String())
+	}
+
+	took := res.duration.Round(time.Millisecond)
+	ended := "finished"
+	if res.status != "" {
+		ended = "ended with " + res.status
+	}
+	switch {
+	case err != nil:
+		stream.send("exit", "failed: "+err.Error())
+	case res.killed:
+		stream.send("exit", fmt.Sprintf("still running %v after SIGINT, killed", s.grace))
+	case res.timedOut:
+		stream.send("exit", fmt.Sprintf("timed out after %v and was interrupted, %s in %v", s.timeout, ended, took))
+	case res.interrupted:
+		stream.send("exit", fmt.Sprintf("interrupted, %s in %v", ended, took))
+	default:
+		stream.send("exit", fmt.Sprintf("%s in %v", ended, took))
+	}
+}
+
+// result describes how a run ended.
+type result struct {
+	duration time.Duration
+	// status is how the process ended unless it succeeded, like "exit
+	// status 2" after a panic.
+	status      string
+	interrupted bool
+	timedOut    bool
+	killed      bool
+}
+
+// exec runs the lesson in a process of its own, in a temporary directory,
+// with its output going to stdout and stderr. Cancelling ctx interrupts it.
+func (s *Server) exec(ctx context.Context, l programs.Lesson, stdout, stderr io.Writer) (result, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return result{}, err
+	}
+	dir, err := os.MkdirTemp("", "playground-")
+	if err != nil {
+		return result{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, exe)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), LessonEnv+"="+l.ID)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	// NOTE: cancelling sends SIGINT like Ctrl-C, WaitDelay kills the lesson if it has not exited grace later
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = s.grace
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return result{}, fmt.Errorf("failed to start the lesson, %w", err)
+	}
+	err = cmd.Wait()
+	res := result{duration: time.Since(start)}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		res.timedOut = true
+	case ctx.Err() != nil:
+		res.interrupted = true
+	}
+	// NOTE: after cancelling, Wait reports ctx.Err() for a lesson that exits cleanly
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) && !errors.Is(err, exec.ErrWaitDelay) && !errors.Is(err, ctx.Err()) {
+		return res, err
+	}
+	if state := cmd.ProcessState; !state.Success() {
+		res.status = state.String()
+		if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signal() == syscall.SIGKILL {
+			res.killed = true
+		}
+	}
+	return res, nil
+}
+
+// stop interrupts a running lesson by cancelling its context.
+func (s *Server) stop(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	cancel, ok := s.runs[r.PathValue("run")]
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "run not found", http.StatusNotFound)
+		return
+	}
+	cancel()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) register(cancel context.CancelFunc) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	id := strconv.Itoa(s.nextID)
+	s.runs[id] = cancel
+	return id
+}
+
+func (s *Server) unregister(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.runs, id)
+}
+
+// eventStream writes Server-Sent Events. The lesson's stdout is copied from
+// its own goroutine, so sends are serialized.
+type eventStream struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func (e *eventStream) send(event, data string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	fmt.Fprintf(e.w, "event: %s\n", event)
+	for line := range strings.SplitSeq(data, "\n") {
+		fmt.Fprintf(e.w, "data: %s\n", line)
+	}
+	fmt.Fprint(e.w, "\n")
+	e.flusher.Flush()
+}
+
+// lineWriter calls emit once per complete line written to it.
+type lineWriter struct {
+	emit    func(string)
+	partial []byte
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	l.partial = append(l.partial, p...)
+	for {
+		i := bytes.IndexByte(l.partial, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		l.emit(strings.TrimRight(string(l.partial[:i]), "\r"))
+		l.partial = l.partial[i+1:]
+	}
+}
+
+// Flush emits a trailing line that did not end with a newline.
+func (l *lineWriter) Flush() {
+	if len(l.partial) > 0 {
+		l.emit(string(l.partial))
+		l.partial = nil
+	}
+}
@@ -0,0 +1,84 @@
+package playground
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"channelspractice/internal/lessons"
+)
+
+func TestProgramsUpToDate(t *testing.T) {
+	root, err := lessons.ModuleRoot(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := lessons.Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join("internal", "playground", "programs")
+	want, err := lessons.Embed(root, all, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string][]byte{}
+	err = filepath.WalkDir(filepath.Join(root, dir), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(filepath.Join(root, dir), path)
+		if err != nil {
+			return err
+		}
+		got[filepath.ToSlash(rel)], err = os.ReadFile(path)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range want {
+		if !bytes.Equal(got[name], data) {
+			t.Errorf("%s is out of date, run go run ./cmd/lessons embed", name)
+		}
+	}
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			t.Errorf("%s is not generated any more, run go run ./cmd/lessons embed", name)
+		}
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		headers map[string]string
+		want    bool
+	}{
+		{"page", "127.0.0.1:8080", nil, true},
+		{"localhost", "localhost:8080", map[string]string{"Sec-Fetch-Site": "same-origin"}, true},
+		{"ipv6 loopback", "[::1]:8080", nil, true},
+		{"same origin header", "127.0.0.1:8080", map[string]string{"Origin": "http://127.0.0.1:8080"}, true},
+		{"other origin", "127.0.0.1:8080", map[string]string{"Origin": "http://example.com"}, false},
+		{"cross site fetch", "127.0.0.1:8080", map[string]string{"Sec-Fetch-Site": "cross-site"}, false},
+		{"rebound host", "evil.example:8080", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/stop/1", nil)
+			r.Host = tt.host
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := sameOrigin(r); got != tt.want {
+				t.Errorf("sameOrigin = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
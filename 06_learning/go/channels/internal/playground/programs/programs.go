@@ -0,0 +1,66 @@
+// Code generated by "go run ./cmd/lessons embed"; DO NOT EDIT.
+
+// Package programs holds a copy of every lesson as an importable package.
+package programs
+
+import (
+	"channelspractice/internal/playground/programs/lesson001"
+	"channelspractice/internal/playground/programs/lesson002"
+	"channelspractice/internal/playground/programs/lesson003"
+	"channelspractice/internal/playground/programs/lesson004"
+	"channelspractice/internal/playground/programs/lesson005"
+	"channelspractice/internal/playground/programs/lesson006"
+	"channelspractice/internal/playground/programs/lesson007"
+	"channelspractice/internal/playground/programs/lesson008"
+	"channelspractice/internal/playground/programs/lesson009"
+	"channelspractice/internal/playground/programs/lesson010"
+	"channelspractice/internal/playground/programs/lesson011"
+	"channelspractice/internal/playground/programs/lesson012"
+	"channelspractice/internal/playground/programs/lesson013"
+	"channelspractice/internal/playground/programs/lesson013b"
+	"channelspractice/internal/playground/programs/lesson014"
+	"channelspractice/internal/playground/programs/lesson_015"
+	"channelspractice/internal/playground/programs/lesson_016"
+	"channelspractice/internal/playground/programs/lesson_017"
+	"channelspractice/internal/playground/programs/lesson_018"
+	"channelspractice/internal/playground/programs/lesson_019"
+	"channelspractice/internal/playground/programs/lesson_019b"
+	"channelspractice/internal/playground/programs/lesson_020"
+	"channelspractice/internal/playground/programs/lesson_021"
+	"channelspractice/internal/playground/programs/lesson_022"
+)
+
+// Lesson is a lesson that can be run by calling Main.
+type Lesson struct {
+	ID    string
+	Title string
+	Main  func()
+}
+
+// Lessons are the lessons in order.
+var Lessons = []Lesson{
+	{ID: "1", Title: "Basic Channel Creation", Main: lesson001.Main},
+	{ID: "2", Title: "Buffered Channels", Main: lesson002.Main},
+	{ID: "3", Title: "Directional Channels", Main: lesson003.Main},
+	{ID: "4", Title: "Select Statement", Main: lesson004.Main},
+	{ID: "5", Title: "Timeouts with select", Main: lesson005.Main},
+	{ID: "6", Title: "Done Channel Pattern", Main: lesson006.Main},
+	{ID: "7", Title: "Worker Pool (basic)", Main: lesson007.Main},
+	{ID: "8", Title: "sync.WaitGroup", Main: lesson008.Main},
+	{ID: "9", Title: "Fan-Out / Fan-In", Main: lesson009.Main},
+	{ID: "10", Title: "Context for Cancellation", Main: lesson010.Main},
+	{ID: "11", Title: "Context with Timeout and Deadline", Main: lesson011.Main},
+	{ID: "12", Title: "Graceful Shutdown with OS Signals", Main: lesson012.Main},
+	{ID: "13", Title: "errgroup", Main: lesson013.Main},
+	{ID: "13b", Title: "errgroup + Signal Handling Combined", Main: lesson013b.Main},
+	{ID: "14", Title: "Semaphores with Buffered Channels", Main: lesson014.Main},
+	{ID: "15", Title: "Rate Limiting with time.Ticker", Main: lesson_015.Main},
+	{ID: "16", Title: "Worker Pool", Main: lesson_016.Main},
+	{ID: "17", Title: "Advanced Select Patterns", Main: lesson_017.Main},
+	{ID: "18", Title: "Worker Pool with Context and errgroup", Main: lesson_018.Main},
+	{ID: "19", Title: "Pipelines with Error Handling", Main: lesson_019.Main},
+	{ID: "19b", Title: "Pipelines with errgroup", Main: lesson_019b.Main},
+	{ID: "20", Title: "Or-Done Pattern and Channel Utilities", Main: lesson_020.Main},
+	{ID: "21", Title: "Tee Pattern - Split One Channel to Multiple Outputs", Main: lesson_021.Main},
+	{ID: "22", Title: "Bridge Pattern - Flatten Channel of Channels", Main: lesson_022.Main},
+}
@@ -0,0 +1,143 @@
+// Package race runs several attempts at the same operation and keeps the
+// first one that succeeds. It generalises the select of lesson 4 to any
+// number of functions and the timeout race of lesson 5 to backup attempts.
+//
+// Attempts receive a context that is cancelled as soon as a winner is
+// known. FirstOf and Hedge return only after every attempt they started
+// has returned, so no goroutine outlives the call; an attempt that ignores
+// its context delays the return instead of leaking.
+package race
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Func is one attempt. attempt is its index, starting at 0.
+type Func[T any] func(ctx context.Context, attempt int) (T, error)
+
+// AttemptError is the error of one failed attempt.
+type AttemptError struct {
+	Attempt int
+	Err     error
+}
+
+func (e *AttemptError) Error() string {
+	return fmt.Sprintf("attempt %d: %v", e.Attempt, e.Err)
+}
+
+func (e *AttemptError) Unwrap() error { return e.Err }
+
+type result[T any] struct {
+	attempt int
+	val     T
+	err     error
+}
+
+// FirstOf runs every fn at once and returns the value of the first one
+// that succeeds along with its index. The others are cancelled. When all
+// of them fail the error joins an *AttemptError per fn and the index is
+// -1; when ctx is done first it is ctx.Err().
+func FirstOf[T any](ctx context.Context, fns ...func(ctx context.Context) (T, error)) (T, int, error) {
+	attempts := make([]Func[T], len(fns))
+	for i, fn := range fns {
+		attempts[i] = func(ctx context.Context, _ int) (T, error) { return fn(ctx) }
+	}
+	return run(ctx, attempts, 0)
+}
+
+// Hedge calls fn and, if it has not succeeded within delay, starts another
+// attempt alongside it, up to maxAttempts in total. A failed attempt
+// starts the next one right away instead of waiting for the delay. The
+// first success wins and the rest are cancelled. Errors are reported as
+// for FirstOf.
+func Hedge[T any](ctx context.Context, fn Func[T], delay time.Duration, maxAttempts int) (T, int, error) {
+	attempts := make([]Func[T], max(maxAttempts, 1))
+	for i := range attempts {
+		attempts[i] = fn
+	}
+	return run(ctx, attempts, delay)
+}
+
+// run starts attempts one after the other, the next one when delay passes
+// or the previous one fails. A delay of 0 starts them all at once.
+func run[T any](ctx context.Context, attempts []Func[T], delay time.Duration) (T, int, error) {
+	var zero T
+	if len(attempts) == 0 {
+		return zero, -1, errors.New("race: no attempts")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// NOTE: room for every attempt, so an attempt never waits for run to read its result
+	results := make(chan result[T], len(attempts))
+	start := func(i int) {
+		go func() {
+			v, err := attempts[i](ctx, i)
+			results <- result[T]{i, v, err}
+		}()
+	}
+
+	started, finished := 0, 0
+	var errs []error
+	// wait collects every attempt still running so none outlives the call.
+	wait := func() {
+		for ; finished < started; finished++ {
+			<-results
+		}
+	}
+
+	var timer *time.Timer
+	var tick <-chan time.Time
+	next := func() {
+		start(started)
+		started++
+		if delay <= 0 {
+			for ; started < len(attempts); started++ {
+				start(started)
+			}
+			return
+		}
+		if started < len(attempts) {
+			if timer == nil {
+				timer = time.NewTimer(delay)
+			} else {
+				timer.Reset(delay)
+			}
+			tick = timer.C
+		} else {
+			tick = nil
+		}
+	}
+	next()
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for finished < started {
+		select {
+		case <-ctx.Done():
+			cancel()
+			wait()
+			return zero, -1, ctx.Err()
+		case <-tick:
+			next()
+		case r := <-results:
+			finished++
+			if r.err == nil {
+				cancel()
+				wait()
+				return r.val, r.attempt, nil
+			}
+			errs = append(errs, &AttemptError{r.attempt, r.err})
+			if started < len(attempts) {
+				next()
+			}
+		}
+	}
+	return zero, -1, errors.Join(errs...)
+}
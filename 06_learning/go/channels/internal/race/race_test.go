@@ -0,0 +1,111 @@
+package race
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// starts records when each attempt started, relative to the first one.
+type starts struct {
+	mu    sync.Mutex
+	first time.Time
+	at    map[int]time.Duration
+}
+
+func (s *starts) record(attempt int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if s.at == nil {
+		s.first, s.at = now, map[int]time.Duration{}
+	}
+	s.at[attempt] = now.Sub(s.first)
+}
+
+func TestHedgeFiresAfterTheDelay(t *testing.T) {
+	const delay = 30 * time.Millisecond
+	var s starts
+	// NOTE: attempt 0 hangs until cancelled, the hedge started after delay answers
+	fn := func(ctx context.Context, attempt int) (string, error) {
+		s.record(attempt)
+		if attempt == 0 {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "hedged", nil
+	}
+	v, attempt, err := Hedge(context.Background(), fn, delay, 3)
+	if v != "hedged" || attempt != 1 || err != nil {
+		t.Fatalf("Hedge = %q, attempt %d, %v, want the hedge, attempt 1", v, attempt, err)
+	}
+	if len(s.at) != 2 {
+		t.Errorf("started attempts %v, want 0 and 1 only", s.at)
+	}
+	if s.at[1] < delay {
+		t.Errorf("hedge started after %v, before the delay of %v", s.at[1], delay)
+	}
+}
+
+func TestHedgeDoesNotFireForAFastAttempt(t *testing.T) {
+	var s starts
+	fn := func(ctx context.Context, attempt int) (int, error) {
+		s.record(attempt)
+		return attempt, nil
+	}
+	if _, attempt, err := Hedge(context.Background(), fn, 50*time.Millisecond, 3); attempt != 0 || err != nil {
+		t.Errorf("Hedge = attempt %d, %v, want attempt 0", attempt, err)
+	}
+	if len(s.at) != 1 {
+		t.Errorf("started attempts %v, want only the first", s.at)
+	}
+}
+
+func TestHedgeFailureStartsTheNextAttemptAtOnce(t *testing.T) {
+	const delay = time.Second
+	errBusy := errors.New("busy")
+	var s starts
+	fn := func(ctx context.Context, attempt int) (int, error) {
+		s.record(attempt)
+		if attempt < 2 {
+			return 0, errBusy
+		}
+		return attempt, nil
+	}
+	start := time.Now()
+	if _, attempt, err := Hedge(context.Background(), fn, delay, 3); attempt != 2 || err != nil {
+		t.Errorf("Hedge = attempt %d, %v, want attempt 2", attempt, err)
+	}
+	if took := time.Since(start); took >= delay {
+		t.Errorf("took %v, failures waited for the delay", took)
+	}
+
+	_, attempt, err := Hedge(context.Background(), func(ctx context.Context, attempt int) (int, error) {
+		return 0, errBusy
+	}, delay, 2)
+	var ae *AttemptError
+	if attempt != -1 || !errors.Is(err, errBusy) || !errors.As(err, &ae) {
+		t.Errorf("Hedge of failures = attempt %d, %v, want -1 and the joined attempt errors", attempt, err)
+	}
+}
+
+func TestFirstOfCancelsTheLosers(t *testing.T) {
+	cancelled := make(chan struct{})
+	slow := func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return 0, ctx.Err()
+	}
+	fast := func(ctx context.Context) (int, error) { return 1, nil }
+	if v, i, err := FirstOf(context.Background(), slow, fast); v != 1 || i != 1 || err != nil {
+		t.Errorf("FirstOf = %d, index %d, %v, want 1 from index 1", v, i, err)
+	}
+	// NOTE: FirstOf returns only once the loser has returned
+	select {
+	case <-cancelled:
+	default:
+		t.Error("FirstOf returned before the losing attempt")
+	}
+}
@@ -0,0 +1,144 @@
+// Package recorder captures the channel operations of a lesson and renders
+// them as documentation: a Mermaid sequence diagram of who sent what to
+// whom, or a static SVG timeline with one lane per goroutine.
+//
+// Channels must be chanx.Chan values; goroutines started with chanx.Go or
+// labelled with chanx.Label show up under their names.
+package recorder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"channelspractice/internal/chanx"
+)
+
+// Env names the file a lesson writes its recording to, see FromEnv. A
+// ".svg" extension produces a timeline, anything else Mermaid.
+const Env = "CHANX_TRACE"
+
+// Event is a completed channel operation.
+type Event struct {
+	chanx.Event
+	Seq int
+	At  time.Duration
+}
+
+// Recorder is a chanx.Hook that keeps every completed operation.
+type Recorder struct {
+	mu     sync.Mutex
+	start  time.Time
+	events []Event
+}
+
+// New returns an empty recorder. Install it with chanx.SetHook.
+func New() *Recorder {
+	return &Recorder{start: time.Now()}
+}
+
+func (r *Recorder) Before(chanx.Event) {}
+
+func (r *Recorder) After(e chanx.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, Event{Event: e, Seq: len(r.events), At: time.Since(r.start)})
+}
+
+// Events returns a copy of the recorded events in the order they completed.
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+// FromEnv starts recording when the Env variable is set and returns a
+// function that writes the recording; call it when the lesson finishes:
+//
+//	defer recorder.FromEnv()()
+func FromEnv() func() {
+	path := os.Getenv(Env)
+	if path == "" {
+		return func() {}
+	}
+	r := New()
+	chanx.SetHook(r)
+	return func() {
+		chanx.SetHook(nil)
+		if err := r.WriteFile(path); err != nil {
+			fmt.Fprintf(os.Stderr, "recorder: %v\n", err)
+		}
+	}
+}
+
+// WriteFile renders the recording to path, as SVG for a ".svg" file and
+// as a Mermaid diagram otherwise.
+func (r *Recorder) WriteFile(path string) error {
+	var b strings.Builder
+	if strings.EqualFold(filepath.Ext(path), ".svg") {
+		WriteSVG(&b, r.Events())
+	} else {
+		WriteMermaid(&b, r.Events())
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write recording, %w", err)
+	}
+	return nil
+}
+
+// Message is a value that travelled from one goroutine to another.
+type Message struct {
+	Send, Recv Event
+}
+
+// Pair matches every receive with the send that delivered its value.
+// Channels are FIFO, so the earliest unmatched send of the same value on
+// the same channel is taken; when values repeat across concurrent senders
+// the pairing is a best guess. Closes and receives from a closed channel
+// are returned as unpaired events.
+func Pair(events []Event) (messages []Message, other []Event) {
+	pending := map[string][]Event{}
+	for _, e := range events {
+		if e.Op == chanx.Send {
+			pending[e.Chan] = append(pending[e.Chan], e)
+		}
+	}
+	for _, e := range events {
+		switch {
+		case e.Op == chanx.Close || (e.Op == chanx.Recv && !e.OK):
+			other = append(other, e)
+		case e.Op == chanx.Recv:
+			sends := pending[e.Chan]
+			idx := 0
+			for i, s := range sends {
+				if fmt.Sprint(s.Value) == fmt.Sprint(e.Value) {
+					idx = i
+					break
+				}
+			}
+			if len(sends) == 0 {
+				other = append(other, e)
+				continue
+			}
+			messages = append(messages, Message{Send: sends[idx], Recv: e})
+			pending[e.Chan] = append(sends[:idx:idx], sends[idx+1:]...)
+		}
+	}
+	return messages, other
+}
+
+// participants lists goroutines in order of first appearance.
+func participants(events []Event) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, e := range events {
+		if !seen[e.Goroutine] {
+			seen[e.Goroutine] = true
+			names = append(names, e.Goroutine)
+		}
+	}
+	return names
+}
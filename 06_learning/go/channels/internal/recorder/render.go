@@ -0,0 +1,126 @@
+package recorder
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"channelspractice/internal/chanx"
+)
+
+// WriteMermaid renders events as a Mermaid sequence diagram. Every paired
+// send and receive becomes an arrow from the sender to the receiver,
+// labelled with the channel and the value; closes become notes.
+func WriteMermaid(w io.Writer, events []Event) {
+	names := participants(events)
+	alias := map[string]string{}
+	fmt.Fprintf(w, "sequenceDiagram\n")
+	for i, name := range names {
+		alias[name] = fmt.Sprintf("g%d", i)
+		fmt.Fprintf(w, "    participant %s as %s\n", alias[name], mermaidText(name))
+	}
+
+	type line struct {
+		seq  int
+		text string
+	}
+	var lines []line
+	messages, other := Pair(events)
+	for _, m := range messages {
+		lines = append(lines, line{min(m.Send.Seq, m.Recv.Seq), fmt.Sprintf("    %s->>%s: %s: %s",
+			alias[m.Send.Goroutine], alias[m.Recv.Goroutine], mermaidText(m.Recv.Chan), mermaidText(fmt.Sprint(m.Recv.Value)))})
+	}
+	for _, e := range other {
+		var note string
+		switch {
+		case e.Op == chanx.Close:
+			note = "close(" + e.Chan + ")"
+		case e.Op == chanx.Recv && !e.OK:
+			note = e.Chan + " closed"
+		default:
+			note = fmt.Sprintf("recv %v from %s", e.Value, e.Chan)
+		}
+		lines = append(lines, line{e.Seq, fmt.Sprintf("    Note over %s: %s", alias[e.Goroutine], mermaidText(note))})
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].seq < lines[j].seq })
+	for _, l := range lines {
+		fmt.Fprintln(w, l.text)
+	}
+}
+
+// mermaidText strips the characters Mermaid treats as syntax in labels.
+func mermaidText(s string) string {
+	return strings.NewReplacer(";", ",", "#", "", "\n", " ").Replace(s)
+}
+
+const (
+	laneHeight  = 48
+	labelWidth  = 160
+	plotWidth   = 900
+	marginTop   = 30
+	markerSize  = 5
+	axisSpacing = 100
+)
+
+// WriteSVG renders events as a static timeline: one lane per goroutine,
+// time flowing to the right, filled dots for sends, hollow dots for
+// receives, crosses for closes and an arrow per delivered value.
+func WriteSVG(w io.Writer, events []Event) {
+	names := participants(events)
+	lane := map[string]int{}
+	for i, name := range names {
+		lane[name] = i
+	}
+
+	var end time.Duration
+	for _, e := range events {
+		end = max(end, e.At)
+	}
+	end = max(end, time.Millisecond)
+	x := func(at time.Duration) float64 {
+		return labelWidth + float64(at)/float64(end)*plotWidth
+	}
+	y := func(name string) float64 {
+		return marginTop + float64(lane[name])*laneHeight + laneHeight/2
+	}
+
+	width := labelWidth + plotWidth + 40
+	height := marginTop + len(names)*laneHeight + 30
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="monospace" font-size="11">`+"\n", width, height)
+	fmt.Fprintf(w, `<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="6" markerHeight="6" orient="auto"><path d="M0,0 L10,5 L0,10 z" fill="#555"/></marker></defs>`+"\n")
+	fmt.Fprintf(w, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+
+	for _, name := range names {
+		fmt.Fprintf(w, `<text x="8" y="%.1f" dominant-baseline="middle">%s</text>`+"\n", y(name), html.EscapeString(name))
+		fmt.Fprintf(w, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#ddd"/>`+"\n", labelWidth, y(name), labelWidth+plotWidth, y(name))
+	}
+
+	axisY := marginTop + len(names)*laneHeight + 15
+	for px := 0; px <= plotWidth; px += axisSpacing {
+		at := time.Duration(float64(end) * float64(px) / plotWidth)
+		fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="middle" fill="#888">%v</text>`+"\n", labelWidth+px, axisY, at.Round(time.Millisecond))
+	}
+
+	messages, other := Pair(events)
+	for _, m := range messages {
+		x1, y1, x2, y2 := x(m.Send.At), y(m.Send.Goroutine), x(m.Recv.At), y(m.Recv.Goroutine)
+		fmt.Fprintf(w, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#555" marker-end="url(#arrow)"><title>%s</title></line>`+"\n",
+			x1, y1, x2, y2, html.EscapeString(fmt.Sprintf("%s: %v", m.Recv.Chan, m.Recv.Value)))
+		fmt.Fprintf(w, `<circle cx="%.1f" cy="%.1f" r="%d" fill="#1f77b4"/>`+"\n", x1, y1, markerSize)
+		fmt.Fprintf(w, `<circle cx="%.1f" cy="%.1f" r="%d" fill="white" stroke="#1f77b4"/>`+"\n", x2, y2, markerSize)
+		fmt.Fprintf(w, `<text x="%.1f" y="%.1f" text-anchor="middle">%s</text>`+"\n", x2, y2-9, html.EscapeString(fmt.Sprint(m.Recv.Value)))
+	}
+	for _, e := range other {
+		cx, cy := x(e.At), y(e.Goroutine)
+		label := e.Chan + " closed"
+		if e.Op == chanx.Close {
+			label = "close(" + e.Chan + ")"
+		}
+		fmt.Fprintf(w, `<path d="M%.1f,%.1f l%d,%d m0,%d l%d,%d" stroke="#d62728" stroke-width="2"><title>%s</title></path>`+"\n",
+			cx-markerSize, cy-markerSize, 2*markerSize, 2*markerSize, -2*markerSize, -2*markerSize, 2*markerSize, html.EscapeString(label))
+	}
+	fmt.Fprintf(w, "</svg>\n")
+}
@@ -0,0 +1,202 @@
+// Package reorder puts items back in sequence. Results of a worker pool,
+// of merge in lesson 20 or of bridge in lesson 22 arrive in whatever
+// order the goroutines finished; a Buffer holds early items back until
+// every item before them went out.
+//
+// Items only need to carry a sequence number, so any upstream works:
+//
+//	buf := reorder.New(func(r result) int { return r.seq }, reorder.Options{GapTimeout: time.Second})
+//	g.Go(func() error { return buf.Run(ctx, results, ordered) })
+package reorder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Policy decides what happens when a sequence number is missing for too
+// long or the buffer is full.
+type Policy int
+
+const (
+	// Skip gives up on the missing numbers and carries on with the next
+	// buffered item, reporting the gap to OnSkip.
+	Skip Policy = iota
+	// Fail stops Run with an error.
+	Fail
+)
+
+var (
+	// ErrGap is returned by Run when a gap timed out under Fail.
+	ErrGap = errors.New("reorder: sequence gap timed out")
+	// ErrOverflow is returned by Run when the buffer filled up under Fail.
+	ErrOverflow = errors.New("reorder: buffer full")
+)
+
+// Options configure a buffer. Zero values get defaults.
+type Options struct {
+	// Start is the first sequence number, 0 by default.
+	Start int
+	// GapTimeout is how long items wait for a missing number before OnGap
+	// applies. 0 waits until the input closes.
+	GapTimeout time.Duration
+	// OnGap applies when a gap times out or the input closes with a gap,
+	// Skip by default.
+	OnGap Policy
+	// MaxBuffered bounds the items held back, 1024 by default.
+	MaxBuffered int
+	// OnOverflow applies when an item arrives while MaxBuffered items are
+	// held back, Skip by default: the gap is skipped right away to make
+	// room.
+	OnOverflow Policy
+	// OnSkip is called with every range of numbers given up on, to and
+	// including.
+	OnSkip func(from, to int)
+	// OnLate is called for items that arrive after their number was
+	// skipped, or twice. They are dropped.
+	OnLate func(seq int)
+}
+
+// Buffer reorders items of type T by the sequence number seq returns.
+type Buffer[T any] struct {
+	seq  func(T) int
+	opts Options
+}
+
+// New returns a buffer.
+func New[T any](seq func(T) int, opts Options) *Buffer[T] {
+	if opts.MaxBuffered <= 0 {
+		opts.MaxBuffered = 1024
+	}
+	return &Buffer[T]{seq: seq, opts: opts}
+}
+
+// Run reads in and sends its items to out in sequence until in is closed,
+// ctx is done or a policy fails. It closes out when it returns.
+func (b *Buffer[T]) Run(ctx context.Context, in <-chan T, out chan<- T) error {
+	defer close(out)
+	var (
+		next    = b.opts.Start
+		pending = map[int]T{}
+		timer   *time.Timer
+		timeout <-chan time.Time
+	)
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	// flush sends every item that is next in sequence.
+	flush := func() error {
+		for {
+			v, ok := pending[next]
+			if !ok {
+				return nil
+			}
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			delete(pending, next)
+			next++
+		}
+	}
+	// skip gives up on the numbers up to the lowest one buffered.
+	skip := func() {
+		lowest := next
+		for seq := range pending {
+			if lowest == next || seq < lowest {
+				lowest = seq
+			}
+		}
+		if b.opts.OnSkip != nil {
+			b.opts.OnSkip(next, lowest-1)
+		}
+		next = lowest
+	}
+	// arm starts the gap timer when items wait for a missing number and
+	// stops it when nothing does.
+	arm := func(restart bool) {
+		if b.opts.GapTimeout <= 0 {
+			return
+		}
+		if len(pending) == 0 {
+			timeout = nil
+			return
+		}
+		if timeout != nil && !restart {
+			return
+		}
+		if timer == nil {
+			timer = time.NewTimer(b.opts.GapTimeout)
+		} else {
+			timer.Reset(b.opts.GapTimeout)
+		}
+		timeout = timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-timeout:
+			timeout = nil
+			if b.opts.OnGap == Fail {
+				return fmt.Errorf("%w, waited %v for %d", ErrGap, b.opts.GapTimeout, next)
+			}
+			skip()
+			if err := flush(); err != nil {
+				return err
+			}
+			arm(true)
+
+		case v, ok := <-in:
+			if !ok {
+				for len(pending) > 0 {
+					if b.opts.OnGap == Fail {
+						return fmt.Errorf("%w, input closed while waiting for %d", ErrGap, next)
+					}
+					skip()
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			seq := b.seq(v)
+			if _, dup := pending[seq]; dup || seq < next {
+				if b.opts.OnLate != nil {
+					b.opts.OnLate(seq)
+				}
+				continue
+			}
+			if seq != next && len(pending) >= b.opts.MaxBuffered {
+				if b.opts.OnOverflow == Fail {
+					return fmt.Errorf("%w, %d items wait for %d", ErrOverflow, len(pending), next)
+				}
+				// NOTE: skip up to the lowest item including this one, so it is not skipped itself
+				pending[seq] = v
+				skip()
+				if err := flush(); err != nil {
+					return err
+				}
+				arm(true)
+				continue
+			}
+
+			pending[seq] = v
+			before := next
+			if err := flush(); err != nil {
+				return err
+			}
+			// NOTE: the wait for a missing number starts over whenever the sequence moves on
+			arm(next != before)
+		}
+	}
+}
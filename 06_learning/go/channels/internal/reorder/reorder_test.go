@@ -0,0 +1,84 @@
+package reorder
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func identity(v int) int { return v }
+
+// gap is a range of numbers given up on, as passed to OnSkip.
+type gap struct{ from, to int }
+
+// run feeds items to a buffer with opts, waiting pause between them, and
+// returns what came out and the error of Run.
+func run(opts Options, pause time.Duration, items ...int) ([]int, error) {
+	in, out := make(chan int), make(chan int)
+	errc := make(chan error, 1)
+	go func() { errc <- New(identity, opts).Run(context.Background(), in, out) }()
+	go func() {
+		defer close(in)
+		for _, v := range items {
+			time.Sleep(pause)
+			in <- v
+		}
+	}()
+	var got []int
+	for v := range out {
+		got = append(got, v)
+	}
+	return got, <-errc
+}
+
+func TestOutOfOrderItemsComeOutInSequence(t *testing.T) {
+	got, err := run(Options{}, 0, 2, 0, 3, 1, 5, 4)
+	if err != nil || !slices.Equal(got, []int{0, 1, 2, 3, 4, 5}) {
+		t.Errorf("got %v, %v, want 0 to 5 in order", got, err)
+	}
+}
+
+func TestGapIsSkippedAfterTheTimeout(t *testing.T) {
+	var skipped []gap
+	var late []int
+	opts := Options{
+		GapTimeout: 20 * time.Millisecond,
+		OnSkip:     func(from, to int) { skipped = append(skipped, gap{from, to}) },
+		OnLate:     func(seq int) { late = append(late, seq) },
+	}
+	// NOTE: 1 and 2 are missing until after the timeout, 2 then arrives late
+	got, err := run(opts, 40*time.Millisecond, 0, 3, 4, 2)
+	if err != nil || !slices.Equal(got, []int{0, 3, 4}) {
+		t.Errorf("got %v, %v, want 0, 3, 4", got, err)
+	}
+	if want := []gap{{1, 2}}; !slices.Equal(skipped, want) {
+		t.Errorf("skipped %v, want %v", skipped, want)
+	}
+	if !slices.Equal(late, []int{2}) {
+		t.Errorf("late items %v, want [2]", late)
+	}
+}
+
+func TestGapFails(t *testing.T) {
+	got, err := run(Options{GapTimeout: 20 * time.Millisecond, OnGap: Fail}, 40*time.Millisecond, 0, 2, 1)
+	if !errors.Is(err, ErrGap) {
+		t.Errorf("Run = %v, want ErrGap", err)
+	}
+	if !slices.Equal(got, []int{0}) {
+		t.Errorf("got %v before failing, want [0]", got)
+	}
+}
+
+func TestOverflowSkipsToMakeRoom(t *testing.T) {
+	var skipped []gap
+	opts := Options{MaxBuffered: 2, OnSkip: func(from, to int) { skipped = append(skipped, gap{from, to}) }}
+	got, err := run(opts, 0, 0, 3, 4, 5, 6)
+	if err != nil || !slices.Equal(got, []int{0, 3, 4, 5, 6}) {
+		t.Errorf("got %v, %v, want 0, 3, 4, 5, 6", got, err)
+	}
+	if want := []gap{{1, 2}}; !slices.Equal(skipped, want) {
+		t.Errorf("skipped %v, want %v", skipped, want)
+	}
+}
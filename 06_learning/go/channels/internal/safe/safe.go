@@ -0,0 +1,48 @@
+// Package safe turns panics in workers and pipeline stages into errors. A
+// panic in any goroutine takes the whole process down, so every goroutine
+// that runs user work recovers at its boundary and hands a *PanicError to
+// the error path the lesson already has: the errgroup, an error channel
+// or a list of failed jobs.
+//
+//	g.Go(safe.Func(func() error {
+//		return work(ctx)
+//	}))
+package safe
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is a recovered panic with the stack of the goroutine that
+// panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("recovered panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value when it is an error, so errors.Is and
+// errors.As see through the panic.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Call runs fn and returns its error, or a *PanicError if it panicked.
+func Call(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+// Func wraps fn so it recovers, for errgroup.Group.Go and the like.
+func Func(fn func() error) func() error {
+	return func() error { return Call(fn) }
+}
@@ -0,0 +1,73 @@
+package safe_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/sync/errgroup"
+
+	"channelspractice/internal/safe"
+)
+
+// explode panics on the bad item.
+func explode(n, bad int) int {
+	if n == bad {
+		panic(fmt.Sprintf("bad item %d", n))
+	}
+	return n * 2
+}
+
+// checkPanic fails unless err holds a *safe.PanicError with value whose
+// stack shows the panic in explode.
+func checkPanic(t *testing.T, err error, value any) {
+	t.Helper()
+	var pe *safe.PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("error %v is not a *safe.PanicError", err)
+	}
+	if pe.Value != value {
+		t.Errorf("Value = %v, want %v", pe.Value, value)
+	}
+	if !strings.Contains(string(pe.Stack), "safe_test.explode(") {
+		t.Errorf("stack does not show where the panic happened:\n%s", pe.Stack)
+	}
+}
+
+func TestCall(t *testing.T) {
+	if err := safe.Call(func() error { return nil }); err != nil {
+		t.Errorf("Call = %v, want nil", err)
+	}
+	want := errors.New("plain error")
+	if err := safe.Call(func() error { return want }); err != want {
+		t.Errorf("Call = %v, want %v", err, want)
+	}
+
+	err := safe.Call(func() error {
+		explode(1, 1)
+		return nil
+	})
+	checkPanic(t, err, "bad item 1")
+
+	// NOTE: a panic with an error value stays visible to errors.Is
+	err = safe.Call(func() error { panic(io.ErrUnexpectedEOF) })
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("errors.Is(%v, io.ErrUnexpectedEOF) = false", err)
+	}
+}
+
+func TestFunc(t *testing.T) {
+	g, ctx := errgroup.WithContext(context.Background())
+	g.Go(safe.Func(func() error {
+		explode(7, 7)
+		return nil
+	}))
+	g.Go(safe.Func(func() error {
+		<-ctx.Done()
+		return nil
+	}))
+	checkPanic(t, g.Wait(), "bad item 7")
+}
@@ -0,0 +1,150 @@
+// Package scatter sends one request to several branches, typically
+// replicas, and gathers a quorum of answers. Where lesson 9 fans in every
+// branch and waits for all of them, Gather returns as soon as enough
+// branches agree to answer, or as soon as too many have failed for that
+// to happen, and cancels the branches still running.
+package scatter
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Func is one branch. branch is its index, starting at 0.
+type Func[T any] func(ctx context.Context, branch int) (T, error)
+
+// Branch is the outcome of one branch.
+type Branch[T any] struct {
+	Index   int
+	Value   T
+	Err     error
+	Latency time.Duration
+	// Cancelled is set when the branch was still running when Gather
+	// decided and gave up with the context error. A branch that finished
+	// anyway keeps its value.
+	Cancelled bool
+}
+
+// Result holds every branch, in index order.
+type Result[T any] struct {
+	Branches  []Branch[T]
+	Successes int
+}
+
+// Values returns the values of the branches that succeeded, in the order
+// they finished.
+func (r *Result[T]) Values() []T {
+	branches := slices.Clone(r.Branches)
+	slices.SortStableFunc(branches, func(a, b Branch[T]) int { return cmp.Compare(a.Latency, b.Latency) })
+	var vals []T
+	for _, b := range branches {
+		if b.Err == nil && !b.Cancelled {
+			vals = append(vals, b.Value)
+		}
+	}
+	return vals
+}
+
+// QuorumError is returned when so many branches failed that the quorum
+// can no longer be reached.
+type QuorumError struct {
+	Quorum, Successes, Failures int
+	Errs                        []error
+}
+
+func (e *QuorumError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("quorum of %d impossible, %d succeeded and %d failed: %s", e.Quorum, e.Successes, e.Failures, strings.Join(msgs, "; "))
+}
+
+func (e *QuorumError) Unwrap() []error { return e.Errs }
+
+type outcome[T any] struct {
+	branch int
+	val    T
+	err    error
+	at     time.Time
+}
+
+// Gather runs fn for branches 0 to n-1 at once and returns when quorum of
+// them succeeded. It fails with a *QuorumError as soon as more than
+// n-quorum branches failed, and with ctx.Err() when ctx is done first.
+// Either way the remaining branches are cancelled and awaited before it
+// returns, and the result holds the error and latency of every branch,
+// including the ones that were cancelled.
+func Gather[T any](ctx context.Context, n int, fn Func[T], quorum int) (*Result[T], error) {
+	if n <= 0 || quorum <= 0 || quorum > n {
+		return nil, fmt.Errorf("scatter: quorum %d out of range for %d branches", quorum, n)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	// NOTE: one slot per branch, a branch never waits for Gather to read
+	outcomes := make(chan outcome[T], n)
+	for i := range n {
+		go func() {
+			v, err := fn(ctx, i)
+			outcomes <- outcome[T]{i, v, err, time.Now()}
+		}()
+	}
+
+	res := &Result[T]{Branches: make([]Branch[T], n)}
+	record := func(o outcome[T], cancelled bool) {
+		res.Branches[o.branch] = Branch[T]{
+			Index:     o.branch,
+			Value:     o.val,
+			Err:       o.err,
+			Latency:   o.at.Sub(start),
+			Cancelled: cancelled,
+		}
+	}
+
+	var (
+		errs     []error
+		finished int
+		err      error
+	)
+loop:
+	for finished < n {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break loop
+		case o := <-outcomes:
+			finished++
+			record(o, false)
+			if o.err != nil {
+				errs = append(errs, fmt.Errorf("branch %d: %w", o.branch, o.err))
+			} else {
+				res.Successes++
+			}
+			switch {
+			case res.Successes >= quorum:
+				break loop
+			case len(errs) > n-quorum:
+				err = &QuorumError{Quorum: quorum, Successes: res.Successes, Failures: len(errs), Errs: errs}
+				break loop
+			}
+		}
+	}
+
+	cancel()
+	for ; finished < n; finished++ {
+		o := <-outcomes
+		// NOTE: a branch may have finished before it saw the cancel, only the context error means it was cut short
+		record(o, errors.Is(o.err, ctx.Err()))
+		if o.err == nil {
+			res.Successes++
+		}
+	}
+	return res, err
+}
@@ -0,0 +1,54 @@
+package scatter
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestLateSuccessesAreKept has every branch succeed, most of them after
+// the quorum: branches that finish anyway keep their value, only the one
+// cut short by the cancel is Cancelled.
+func TestLateSuccessesAreKept(t *testing.T) {
+	const n, quorum = 5, 2
+	res, err := Gather(context.Background(), n, func(ctx context.Context, branch int) (int, error) {
+		if branch == n-1 {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		// NOTE: ignores ctx, so branches 2 and 3 succeed after Gather decided
+		time.Sleep(time.Duration(branch) * 20 * time.Millisecond)
+		return branch, nil
+	}, quorum)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, b := range res.Branches {
+		wantCancelled := b.Index == n-1
+		if b.Cancelled != wantCancelled {
+			t.Errorf("branch %d: Cancelled = %t, want %t (err %v)", b.Index, b.Cancelled, wantCancelled, b.Err)
+		}
+	}
+	if got := res.Values(); !slices.Equal(got, []int{0, 1, 2, 3}) {
+		t.Errorf("Values = %v, want [0 1 2 3]", got)
+	}
+	if res.Successes != n-1 {
+		t.Errorf("Successes = %d, want %d", res.Successes, n-1)
+	}
+}
+
+func TestQuorumImpossible(t *testing.T) {
+	_, err := Gather(context.Background(), 3, func(ctx context.Context, branch int) (int, error) {
+		if branch == 0 {
+			return 0, nil
+		}
+		return 0, context.DeadlineExceeded
+	}, 2)
+	var qe *QuorumError
+	if !errors.As(err, &qe) {
+		t.Fatalf("Gather = %v, want a *QuorumError", err)
+	}
+}
@@ -0,0 +1,230 @@
+// Package shed is a bounded queue that sheds load instead of blocking.
+// The buffered jobs channel of lesson 16 blocks the producer once its 10
+// slots are full, and that wait travels up to whoever produced the job. A
+// Queue never blocks the producer: when it is full its Policy decides what
+// to give up, and every item given up is counted by reason.
+package shed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// Policy decides what a full queue gives up.
+type Policy int
+
+const (
+	// RejectNew refuses the item being submitted.
+	RejectNew Policy = iota
+	// DropOldest evicts the item that waited longest to make room, so
+	// the queue holds the freshest work.
+	DropOldest
+	// DropLowest evicts the item with the lowest priority if the new one
+	// ranks higher, and refuses the new one otherwise.
+	DropLowest
+)
+
+// Reason is why an item was shed.
+type Reason int
+
+const (
+	// Full is a new item refused by RejectNew.
+	Full Reason = iota
+	// Oldest is a queued item evicted by DropOldest.
+	Oldest
+	// LowPriority is an item evicted, or refused, by DropLowest.
+	LowPriority
+)
+
+func (r Reason) String() string {
+	switch r {
+	case Full:
+		return "full"
+	case Oldest:
+		return "oldest"
+	case LowPriority:
+		return "low priority"
+	}
+	return fmt.Sprintf("reason(%d)", int(r))
+}
+
+// ErrClosed is returned when submitting to or receiving from a closed,
+// drained queue.
+var ErrClosed = errors.New("shed: queue closed")
+
+// ErrOverloaded is returned by TrySubmit when the submitted item itself
+// was shed. Items evicted to make room for it are reported to OnShed
+// instead.
+type ErrOverloaded struct {
+	Reason   Reason
+	Capacity int
+}
+
+func (e *ErrOverloaded) Error() string {
+	return fmt.Sprintf("shed: queue of %d overloaded, rejected (%s)", e.Capacity, e.Reason)
+}
+
+// Options configure a queue.
+type Options[T any] struct {
+	// Capacity bounds the queued items, 10 by default.
+	Capacity int
+	Policy   Policy
+	// Priority ranks items for DropLowest, higher is more important.
+	// Required for DropLowest.
+	Priority func(T) int
+	// OnShed, if set, is called for every item given up, without the
+	// queue's lock held.
+	OnShed func(v T, r Reason)
+}
+
+// Stats counts what happened to submitted items.
+type Stats struct {
+	Accepted  int
+	Delivered int
+	Shed      map[Reason]int
+}
+
+// Queue is a bounded FIFO queue with a shedding policy.
+type Queue[T any] struct {
+	opts Options[T]
+
+	mu     sync.Mutex
+	items  []T
+	closed bool
+	stats  Stats
+	// ready is closed and replaced whenever an item is queued or the
+	// queue closes.
+	ready chan struct{}
+}
+
+// New returns an empty queue.
+func New[T any](opts Options[T]) *Queue[T] {
+	if opts.Capacity <= 0 {
+		opts.Capacity = 10
+	}
+	if opts.Policy == DropLowest && opts.Priority == nil {
+		panic("shed: DropLowest needs a Priority func")
+	}
+	return &Queue[T]{
+		opts:  opts,
+		items: make([]T, 0, opts.Capacity),
+		stats: Stats{Shed: map[Reason]int{}},
+		ready: make(chan struct{}),
+	}
+}
+
+// TrySubmit queues v without blocking. When the queue is full the policy
+// applies: v is either refused with an *ErrOverloaded or queued in place
+// of an evicted item.
+func (q *Queue[T]) TrySubmit(v T) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return ErrClosed
+	}
+	var (
+		evicted T
+		reason  Reason
+		shed    bool
+	)
+	if len(q.items) >= q.opts.Capacity {
+		switch q.opts.Policy {
+		case DropOldest:
+			evicted, reason, shed = q.items[0], Oldest, true
+			q.items = q.items[1:]
+		case DropLowest:
+			lowest := 0
+			for i, item := range q.items {
+				if q.opts.Priority(item) < q.opts.Priority(q.items[lowest]) {
+					lowest = i
+				}
+			}
+			if q.opts.Priority(v) <= q.opts.Priority(q.items[lowest]) {
+				return q.refuse(v, LowPriority)
+			}
+			evicted, reason, shed = q.items[lowest], LowPriority, true
+			q.items = append(q.items[:lowest], q.items[lowest+1:]...)
+		default:
+			return q.refuse(v, Full)
+		}
+		q.stats.Shed[reason]++
+	}
+	q.items = append(q.items, v)
+	q.stats.Accepted++
+	close(q.ready)
+	q.ready = make(chan struct{})
+	q.mu.Unlock()
+
+	if shed && q.opts.OnShed != nil {
+		q.opts.OnShed(evicted, reason)
+	}
+	return nil
+}
+
+// refuse counts v as shed and unlocks q. q.mu must be held.
+func (q *Queue[T]) refuse(v T, r Reason) error {
+	q.stats.Shed[r]++
+	q.mu.Unlock()
+	if q.opts.OnShed != nil {
+		q.opts.OnShed(v, r)
+	}
+	return &ErrOverloaded{Reason: r, Capacity: q.opts.Capacity}
+}
+
+// Receive returns the oldest queued item, waiting for one until ctx is
+// done. Once the queue is closed it keeps returning the remaining items
+// and then ErrClosed.
+func (q *Queue[T]) Receive(ctx context.Context) (T, error) {
+	for {
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			v := q.items[0]
+			q.items = q.items[1:]
+			q.stats.Delivered++
+			q.mu.Unlock()
+			return v, nil
+		}
+		closed, ready := q.closed, q.ready
+		q.mu.Unlock()
+
+		var zero T
+		if closed {
+			return zero, ErrClosed
+		}
+		select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case <-ready:
+		}
+	}
+}
+
+// Close stops accepting items. Receivers drain what is queued.
+func (q *Queue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.ready)
+}
+
+// Len returns the number of queued items.
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Stats returns a copy of the counters so far.
+func (q *Queue[T]) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	s := q.stats
+	s.Shed = maps.Clone(q.stats.Shed)
+	return s
+}
@@ -0,0 +1,110 @@
+package shed
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+type shedItem struct {
+	v int
+	r Reason
+}
+
+// fill submits vals to a queue with opts and returns it, the items it shed
+// and the error of every submit.
+func fill(opts Options[int], vals ...int) (*Queue[int], *[]shedItem, []error) {
+	shed := &[]shedItem{}
+	opts.OnShed = func(v int, r Reason) { *shed = append(*shed, shedItem{v, r}) }
+	q := New(opts)
+	errs := make([]error, len(vals))
+	for i, v := range vals {
+		errs[i] = q.TrySubmit(v)
+	}
+	return q, shed, errs
+}
+
+// receiveAll closes q and returns what is left in it.
+func receiveAll(t *testing.T, q *Queue[int]) []int {
+	t.Helper()
+	q.Close()
+	var got []int
+	for {
+		v, err := q.Receive(context.Background())
+		if errors.Is(err, ErrClosed) {
+			return got
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+}
+
+func TestRejectNewAtCapacity(t *testing.T) {
+	q, shed, errs := fill(Options[int]{Capacity: 2}, 1, 2, 3)
+	var overloaded *ErrOverloaded
+	if errs[0] != nil || errs[1] != nil || !errors.As(errs[2], &overloaded) || overloaded.Reason != Full {
+		t.Errorf("TrySubmit errors = %v, want the third refused as full", errs)
+	}
+	if want := []shedItem{{3, Full}}; !slices.Equal(*shed, want) {
+		t.Errorf("shed %v, want %v", *shed, want)
+	}
+	if got := receiveAll(t, q); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("queue held %v, want 1, 2", got)
+	}
+	s := q.Stats()
+	if s.Accepted != 2 || s.Delivered != 2 || s.Shed[Full] != 1 {
+		t.Errorf("Stats = %+v, want 2 accepted, 2 delivered, 1 shed as full", s)
+	}
+}
+
+func TestDropOldestAtCapacity(t *testing.T) {
+	q, shed, errs := fill(Options[int]{Capacity: 2, Policy: DropOldest}, 1, 2, 3, 4)
+	if err := errors.Join(errs...); err != nil {
+		t.Errorf("TrySubmit = %v, DropOldest never refuses", err)
+	}
+	if want := []shedItem{{1, Oldest}, {2, Oldest}}; !slices.Equal(*shed, want) {
+		t.Errorf("shed %v, want %v", *shed, want)
+	}
+	if got := receiveAll(t, q); !slices.Equal(got, []int{3, 4}) {
+		t.Errorf("queue held %v, want the freshest 3, 4", got)
+	}
+}
+
+func TestDropLowestAtCapacity(t *testing.T) {
+	opts := Options[int]{Capacity: 2, Policy: DropLowest, Priority: func(v int) int { return v }}
+	q, shed, errs := fill(opts, 5, 2, 1, 8)
+	var overloaded *ErrOverloaded
+	if !errors.As(errs[2], &overloaded) || overloaded.Reason != LowPriority {
+		t.Errorf("submitting 1 = %v, want it refused as low priority", errs[2])
+	}
+	if errs[3] != nil {
+		t.Errorf("submitting 8 = %v, want it to evict 2", errs[3])
+	}
+	if want := []shedItem{{1, LowPriority}, {2, LowPriority}}; !slices.Equal(*shed, want) {
+		t.Errorf("shed %v, want %v", *shed, want)
+	}
+	if got := receiveAll(t, q); !slices.Equal(got, []int{5, 8}) {
+		t.Errorf("queue held %v, want 5, 8", got)
+	}
+}
+
+func TestReceiveWaitsForASubmit(t *testing.T) {
+	q := New(Options[int]{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.TrySubmit(7)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if v, err := q.Receive(ctx); v != 7 || err != nil {
+		t.Errorf("Receive = %d, %v, want 7", v, err)
+	}
+	q.Close()
+	if err := q.TrySubmit(8); !errors.Is(err, ErrClosed) {
+		t.Errorf("TrySubmit after Close = %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,77 @@
+package stepper
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	clearScreen = "\033[H\033[2J"
+	bold        = "\033[1m"
+	reset       = "\033[0m"
+	tailLines   = 8
+)
+
+var stateColor = map[string]string{
+	Running:     "\033[32m",
+	Ready:       "\033[33m",
+	BlockedSend: "\033[31m",
+	BlockedRecv: "\033[31m",
+	Done:        "\033[90m",
+}
+
+// draw repaints the screen when something changed since the last paint.
+func (s *Stepper) draw() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return
+	}
+	s.dirty = false
+
+	var b strings.Builder
+	b.WriteString(clearScreen)
+	mode := "stepping"
+	if s.auto {
+		mode = "running"
+	}
+	fmt.Fprintf(&b, "%schannel stepper%s  %s, %d steps\n", bold, reset, mode, s.steps)
+	fmt.Fprintf(&b, "[enter] step  [n] release goroutine n  [r] run/step  [c] cancel  [s] SIGINT  [q] quit\n\n")
+
+	fmt.Fprintf(&b, "%sCHANNELS%s\n", bold, reset)
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for _, c := range s.channels {
+		state := ""
+		if c.closed {
+			state = "closed"
+		}
+		fmt.Fprintf(tw, "  %s\t%d/%d\t[%s]\t%s\n", c.name, len(c.buf), c.cap, strings.Join(c.buf, " "), state)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(&b, "\n%sGOROUTINES%s\n", bold, reset)
+	tw = tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for i, g := range s.goroutines {
+		fmt.Fprintf(tw, "  %d\t%s\t%s%s%s\t%s\n", i+1, g.name, stateColor[g.state], g.state, reset, g.detail)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(&b, "\n%sLAST OPERATIONS%s\n", bold, reset)
+	for _, line := range tail(s.history, tailLines) {
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+
+	fmt.Fprintf(&b, "\n%sLESSON OUTPUT%s\n", bold, reset)
+	for _, line := range tail(s.output, tailLines) {
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+	fmt.Fprint(s.term, b.String())
+}
+
+func tail(lines []string, n int) []string {
+	if len(lines) > n {
+		return lines[len(lines)-n:]
+	}
+	return lines
+}
@@ -0,0 +1,339 @@
+// Package stepper is a terminal UI that pauses a lesson before every
+// chanx channel operation, so the operations can be stepped through one at
+// a time while watching channel buffers and goroutine states.
+//
+// The UI reads commands as lines from stdin:
+//
+//	<enter>  release the goroutine that has waited longest
+//	<n>      release goroutine number n
+//	r        run freely, toggles back to stepping
+//	c        call the lesson's cancel function
+//	s        send SIGINT to the lesson
+//	q        quit immediately
+package stepper
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"channelspractice/internal/chanx"
+)
+
+// Env enables the stepper when set to a non-empty value, see FromEnv.
+const Env = "CHANX_STEP"
+
+// Goroutine states shown in the UI.
+const (
+	Running     = "running"
+	Ready       = "ready"
+	BlockedSend = "blocked-send"
+	BlockedRecv = "blocked-recv"
+	Done        = "done"
+)
+
+type goroutine struct {
+	name   string
+	state  string
+	detail string
+	// release is set while the goroutine waits for a step.
+	release chan struct{}
+	since   int
+}
+
+type channel struct {
+	name   string
+	cap    int
+	buf    []string
+	closed bool
+}
+
+// Stepper is a chanx hook that gates every channel operation on user input.
+type Stepper struct {
+	mu         sync.Mutex
+	term       io.Writer
+	cancel     func()
+	auto       bool
+	steps      int
+	arrivals   int
+	goroutines []*goroutine
+	channels   []*channel
+	history    []string
+	output     []string
+	dirty      bool
+}
+
+// New returns a stepper that draws on term. cancel is invoked by the "c"
+// command and may be nil.
+func New(term io.Writer, cancel func()) *Stepper {
+	return &Stepper{term: term, cancel: cancel, dirty: true}
+}
+
+// FromEnv starts the stepper when Env is set: it takes over the terminal,
+// captures what the lesson prints and installs itself as the chanx hook.
+// The returned function restores the terminal; call it when the lesson
+// finishes:
+//
+//	defer stepper.FromEnv(cancel)()
+func FromEnv(cancel func()) func() {
+	if os.Getenv(Env) == "" {
+		return func() {}
+	}
+	term := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "stepper: %v\n", err)
+		return func() {}
+	}
+	os.Stdout = w
+
+	s := New(term, cancel)
+	chanx.Label("main")
+	chanx.SetHook(s)
+
+	captured := make(chan struct{})
+	go func() {
+		defer close(captured)
+		s.capture(r)
+	}()
+	go s.commands(os.Stdin)
+	stopDraw := s.drawLoop(50 * time.Millisecond)
+
+	return func() {
+		chanx.SetHook(nil)
+		s.releaseAll()
+		os.Stdout = term
+		w.Close()
+		<-captured
+		stopDraw()
+		s.draw()
+		fmt.Fprintf(term, "\nlesson finished after %d steps\n", s.steps)
+	}
+}
+
+func (s *Stepper) goroutine(name string) *goroutine {
+	for _, g := range s.goroutines {
+		if g.name == name {
+			return g
+		}
+	}
+	g := &goroutine{name: name, state: Running}
+	s.goroutines = append(s.goroutines, g)
+	return g
+}
+
+func (s *Stepper) channel(e chanx.Event) *channel {
+	for _, c := range s.channels {
+		if c.name == e.Chan {
+			return c
+		}
+	}
+	c := &channel{name: e.Chan, cap: e.Cap}
+	s.channels = append(s.channels, c)
+	return c
+}
+
+func describe(e chanx.Event) string {
+	switch e.Op {
+	case chanx.Send:
+		return fmt.Sprintf("send %v on %s", e.Value, e.Chan)
+	case chanx.Recv:
+		return "recv on " + e.Chan
+	default:
+		return "close " + e.Chan
+	}
+}
+
+// Before parks the calling goroutine until it is released by a step.
+func (s *Stepper) Before(e chanx.Event) {
+	s.mu.Lock()
+	s.channel(e)
+	g := s.goroutine(e.Goroutine)
+	g.detail = describe(e)
+	var release chan struct{}
+	if !s.auto {
+		release = make(chan struct{})
+		g.state, g.release = Ready, release
+		s.arrivals++
+		g.since = s.arrivals
+	}
+	s.dirty = true
+	s.mu.Unlock()
+
+	if release != nil {
+		<-release
+	}
+
+	s.mu.Lock()
+	switch e.Op {
+	case chanx.Send:
+		g.state = BlockedSend
+	case chanx.Recv:
+		g.state = BlockedRecv
+	default:
+		g.state = Running
+	}
+	s.dirty = true
+	s.mu.Unlock()
+}
+
+// After records the completed operation in the channel model.
+func (s *Stepper) After(e chanx.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.channel(e)
+	g := s.goroutine(e.Goroutine)
+	g.state, g.detail = Running, ""
+
+	var entry string
+	switch {
+	case e.Op == chanx.Send:
+		if c.cap > 0 {
+			c.buf = append(c.buf, fmt.Sprint(e.Value))
+		}
+		entry = fmt.Sprintf("%s sent %v on %s", e.Goroutine, e.Value, e.Chan)
+	case e.Op == chanx.Recv && !e.OK:
+		entry = fmt.Sprintf("%s saw %s closed", e.Goroutine, e.Chan)
+	case e.Op == chanx.Recv:
+		if len(c.buf) > 0 {
+			c.buf = c.buf[1:]
+		}
+		entry = fmt.Sprintf("%s received %v from %s", e.Goroutine, e.Value, e.Chan)
+	default:
+		c.closed = true
+		entry = fmt.Sprintf("%s closed %s", e.Goroutine, e.Chan)
+	}
+	// NOTE: the model can drift when operations race, the real length wins
+	for len(c.buf) > e.Len {
+		c.buf = c.buf[1:]
+	}
+	s.history = append(s.history, entry)
+	s.dirty = true
+}
+
+func (s *Stepper) Started(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.goroutine(name).state = Running
+	s.dirty = true
+}
+
+func (s *Stepper) Exited(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g := s.goroutine(name)
+	g.state, g.detail = Done, ""
+	s.dirty = true
+}
+
+// step releases goroutine number n, or the longest waiting one when n is 0.
+func (s *Stepper) step(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var next *goroutine
+	for i, g := range s.goroutines {
+		if g.release == nil {
+			continue
+		}
+		if n == i+1 {
+			next = g
+			break
+		}
+		if n == 0 && (next == nil || g.since < next.since) {
+			next = g
+		}
+	}
+	if next == nil {
+		return
+	}
+	close(next.release)
+	next.release = nil
+	s.steps++
+	s.dirty = true
+}
+
+func (s *Stepper) releaseAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, g := range s.goroutines {
+		if g.release != nil {
+			close(g.release)
+			g.release = nil
+		}
+	}
+}
+
+func (s *Stepper) commands(in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		cmd := strings.TrimSpace(scanner.Text())
+		switch cmd {
+		case "":
+			s.step(0)
+		case "r":
+			s.mu.Lock()
+			s.auto = !s.auto
+			s.mu.Unlock()
+			s.releaseAll()
+		case "c":
+			if s.cancel != nil {
+				s.cancel()
+				s.note("cancel called")
+			}
+		case "s":
+			syscall.Kill(os.Getpid(), syscall.SIGINT)
+			s.note("SIGINT sent")
+		case "q":
+			os.Exit(130)
+		default:
+			if n, err := strconv.Atoi(cmd); err == nil {
+				s.step(n)
+			}
+		}
+	}
+}
+
+func (s *Stepper) note(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = append(s.history, ">> "+msg)
+	s.dirty = true
+}
+
+func (s *Stepper) capture(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		s.mu.Lock()
+		s.output = append(s.output, scanner.Text())
+		s.dirty = true
+		s.mu.Unlock()
+	}
+}
+
+func (s *Stepper) drawLoop(every time.Duration) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				s.draw()
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}